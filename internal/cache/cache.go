@@ -0,0 +1,74 @@
+// Package cache keeps the last known status, so it can be read without a
+// request to the server.
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/jsonfile"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+type Entry struct {
+	// Status is nil if the user has no status.
+	Status    *ocs.UserStatus `json:"status"`
+	FetchedAt time.Time       `json:"fetchedAt"`
+}
+
+// Store keeps the status in a JSON file.
+type Store struct {
+	Path string
+}
+
+// DefaultStore returns the store of the current profile in the XDG cache
+// directory.
+func DefaultStore() (Store, error) {
+	name := "nsc/status.json"
+	if profile := ocs.Profile(); profile != "" {
+		name = filepath.Join("nsc", "profiles", profile, "status.json")
+	}
+
+	path, err := xdg.CacheFile(name)
+	if err != nil {
+		return Store{}, err
+	}
+
+	return Store{Path: path}, nil
+}
+
+// Load returns the cached status or nil if there is none.
+func (s Store) Load() (*Entry, error) {
+	entryJson, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	err = json.Unmarshal(entryJson, &entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Save replaces the cached status.
+func (s Store) Save(status *ocs.UserStatus, fetchedAt time.Time) error {
+	return jsonfile.Write(s.Path, Entry{Status: status, FetchedAt: fetchedAt})
+}
+
+// Invalidate removes the cached status, e.g. after a failed request left it in
+// an unknown state.
+func (s Store) Invalidate() error {
+	err := os.Remove(s.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := Store{Path: filepath.Join(t.TempDir(), "status.json")}
+
+	entry, err := store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	fetchedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	status := &ocs.UserStatus{User: "alice", Status: "dnd", Icon: "📅", Message: "In a meeting"}
+	require.NoError(t, store.Save(status, fetchedAt))
+
+	entry, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, status, entry.Status)
+	assert.True(t, fetchedAt.Equal(entry.FetchedAt))
+
+	// A missing status is cached as well.
+	require.NoError(t, store.Save(nil, fetchedAt))
+	entry, err = store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, entry.Status)
+
+	require.NoError(t, store.Invalidate())
+	entry, err = store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+	require.NoError(t, store.Invalidate())
+}
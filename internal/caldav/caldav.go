@@ -0,0 +1,196 @@
+// Package caldav reads calendars and events from the DAV backend of a
+// Nextcloud server (RFC 4791).
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ical"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:displayname/>
+    <d:resourcetype/>
+    <c:supported-calendar-component-set/>
+  </d:prop>
+</d:propfind>`
+
+const calendarQueryBody = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="%s" end="%s"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				DisplayName  string `xml:"DAV: displayname"`
+				ResourceType struct {
+					Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+				} `xml:"DAV: resourcetype"`
+				Components struct {
+					Comps []component `xml:"urn:ietf:params:xml:ns:caldav comp"`
+				} `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set"`
+				CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+type component struct {
+	Name string `xml:"name,attr"`
+}
+
+type Calendar struct {
+	Name string
+
+	// Url is the absolute URL of the calendar collection.
+	Url string
+}
+
+// Client talks to the DAV backend of the server of Auth.
+type Client struct {
+	Auth ocs.Auth
+}
+
+// requestTimeout limits requests to calendar servers.
+const requestTimeout = 30 * time.Second
+
+func (c Client) calendarHome() string {
+	return strings.TrimRight(c.Auth.ServerBaseUrl, "/") + "/remote.php/dav/calendars/" + url.PathEscape(c.Auth.User) + "/"
+}
+
+func (c Client) request(method, target, depth, body string) (multistatus, error) {
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		return multistatus{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", depth)
+	req.SetBasicAuth(c.Auth.User, c.Auth.Password)
+
+	res, err := ocs.NewHTTPClient(requestTimeout).Do(req)
+	if err != nil {
+		return multistatus{}, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return multistatus{}, err
+	}
+
+	if res.StatusCode != http.StatusMultiStatus {
+		return multistatus{}, fmt.Errorf("%s %s", res.Status, string(resBody))
+	}
+
+	var ms multistatus
+	if err := xml.Unmarshal(resBody, &ms); err != nil {
+		return multistatus{}, err
+	}
+
+	return ms, nil
+}
+
+// Calendars discovers the calendars of the user that contain events.
+func (c Client) Calendars() ([]Calendar, error) {
+	home := c.calendarHome()
+	ms, err := c.request("PROPFIND", home, "1", propfindBody)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to discover calendars: %s", err)
+	}
+
+	base, err := url.Parse(home)
+	if err != nil {
+		return nil, err
+	}
+
+	var calendars []Calendar
+	for _, res := range ms.Responses {
+		for _, propstat := range res.Propstats {
+			prop := propstat.Prop
+			if !strings.Contains(propstat.Status, " 200 ") || prop.ResourceType.Calendar == nil {
+				continue
+			}
+
+			comps := prop.Components.Comps
+			if len(comps) > 0 && !slices.Contains(comps, component{Name: "VEVENT"}) {
+				continue
+			}
+
+			href, err := url.Parse(res.Href)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to discover calendars: %s", err)
+			}
+
+			name := prop.DisplayName
+			if name == "" {
+				name = path.Base(href.Path)
+			}
+
+			calendars = append(calendars, Calendar{
+				Name: name,
+				Url:  base.ResolveReference(href).String(),
+			})
+		}
+	}
+
+	return calendars, nil
+}
+
+// Events returns the events of the calendar that have occurrences
+// overlapping [from, to). Recurring events are returned as a whole. Floating
+// times are interpreted in loc.
+func (c Client) Events(calendar Calendar, from, to time.Time, loc *time.Location) ([]ical.Event, error) {
+	const format = "20060102T150405Z"
+	body := fmt.Sprintf(calendarQueryBody, from.UTC().Format(format), to.UTC().Format(format))
+	ms, err := c.request("REPORT", calendar.Url, "1", body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query calendar %s: %s", calendar.Name, err)
+	}
+
+	var events []ical.Event
+	for _, res := range ms.Responses {
+		for _, propstat := range res.Propstats {
+			if propstat.Prop.CalendarData == "" {
+				continue
+			}
+
+			// A single unreadable object must not hide the rest of the
+			// calendar.
+			objectEvents, err := ical.Parse(bytes.NewBufferString(propstat.Prop.CalendarData), loc)
+			if err != nil {
+				log.Printf("Skipping %s: %s", res.Href, err)
+				continue
+			}
+			events = append(events, objectEvents...)
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,119 @@
+package caldav
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const propfindResponse = `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/remote.php/dav/calendars/alice/</d:href>
+    <d:propstat>
+      <d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/remote.php/dav/calendars/alice/personal/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:displayname>Personal</d:displayname>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <cal:supported-calendar-component-set><cal:comp name="VEVENT"/><cal:comp name="VTODO"/></cal:supported-calendar-component-set>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/remote.php/dav/calendars/alice/tasks/</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:displayname>Tasks</d:displayname>
+        <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+        <cal:supported-calendar-component-set><cal:comp name="VTODO"/></cal:supported-calendar-component-set>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/remote.php/dav/calendars/alice/inbox/</d:href>
+    <d:propstat>
+      <d:prop><d:resourcetype><d:collection/><cal:schedule-inbox/></d:resourcetype></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`
+
+const reportResponse = `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/remote.php/dav/calendars/alice/personal/standup.ics</d:href>
+    <d:propstat>
+      <d:prop>
+        <cal:calendar-data>BEGIN:VCALENDAR&#13;
+BEGIN:VEVENT&#13;
+UID:standup&#13;
+SUMMARY:Standup &amp; coffee&#13;
+DTSTART:20261019T090000Z&#13;
+DURATION:PT15M&#13;
+RRULE:FREQ=DAILY&#13;
+END:VEVENT&#13;
+END:VCALENDAR&#13;
+</cal:calendar-data>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`
+
+func TestClient(t *testing.T) {
+	var reportBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "alice" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == "PROPFIND" && r.URL.Path == "/remote.php/dav/calendars/alice/" && r.Header.Get("Depth") == "1":
+			w.WriteHeader(http.StatusMultiStatus)
+			io.WriteString(w, propfindResponse)
+		case r.Method == "REPORT" && r.URL.Path == "/remote.php/dav/calendars/alice/personal/":
+			body, _ := io.ReadAll(r.Body)
+			reportBody = string(body)
+			w.WriteHeader(http.StatusMultiStatus)
+			io.WriteString(w, reportResponse)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := Client{Auth: ocs.Auth{ServerBaseUrl: server.URL, User: "alice", Password: "secret"}}
+
+	calendars, err := client.Calendars()
+	require.NoError(t, err)
+	assert.Equal(t, []Calendar{{Name: "Personal", Url: server.URL + "/remote.php/dav/calendars/alice/personal/"}}, calendars)
+
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	events, err := client.Events(calendars[0], from, from.AddDate(0, 0, 1), time.UTC)
+	require.NoError(t, err)
+	assert.Contains(t, reportBody, `<c:time-range start="20261019T000000Z" end="20261020T000000Z"/>`)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Standup & coffee", events[0].Summary)
+	assert.NotNil(t, events[0].RRule)
+
+	client.Auth.Password = "wrong"
+	_, err = client.Calendars()
+	assert.ErrorContains(t, err, "401")
+	assert.True(t, strings.HasPrefix(err.Error(), "Failed to discover calendars"))
+}
@@ -0,0 +1,231 @@
+// Package calendar resolves timeouts and working hours according to the
+// user's notion of days and weeks.
+package calendar
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+)
+
+var (
+	clockRegexp = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+
+	weekdays = map[string]time.Weekday{
+		"sunday":    time.Sunday,
+		"monday":    time.Monday,
+		"tuesday":   time.Tuesday,
+		"wednesday": time.Wednesday,
+		"thursday":  time.Thursday,
+		"friday":    time.Friday,
+		"saturday":  time.Saturday,
+	}
+)
+
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+func (c Clock) Before(other Clock) bool {
+	return c.Hour < other.Hour || c.Hour == other.Hour && c.Minute < other.Minute
+}
+
+// On returns the time of the clock on the given day.
+func (c Clock) On(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.Hour, c.Minute, 0, 0, day.Location())
+}
+
+type Calendar struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+	DayEnd    Clock
+
+	// WorkingHours maps weekdays to working hours. Days without working
+	// hours are days off.
+	WorkingHours map[time.Weekday]Hours
+
+	// Holidays holds dates like "2026-12-24" without working hours.
+	Holidays map[string]bool
+}
+
+func Default() Calendar {
+	return Calendar{
+		Location:     time.Local,
+		WeekStart:    time.Monday,
+		WorkingHours: defaultWorkingHours(Clock{Hour: 17}),
+	}
+}
+
+// FromConfig builds the calendar from the config. The time zone tz takes
+// precedence over the configured one if it is not empty.
+func FromConfig(cfg config.Config, tz string) (Calendar, error) {
+	cal := Default()
+
+	var err error
+	if tz == "" {
+		tz = cfg.TimeZone
+	}
+	if tz != "" {
+		cal.Location, err = time.LoadLocation(tz)
+		if err != nil {
+			return Calendar{}, fmt.Errorf("Invalid time zone %q", tz)
+		}
+	}
+
+	if cfg.WeekStart != "" {
+		weekday, ok := ParseWeekday(strings.ToLower(cfg.WeekStart))
+		if !ok {
+			return Calendar{}, fmt.Errorf("Invalid week start %q", cfg.WeekStart)
+		}
+		cal.WeekStart = weekday
+	}
+
+	if cfg.DayEnd != "" {
+		clock, ok := ParseClock(strings.ToLower(cfg.DayEnd))
+		if !ok {
+			return Calendar{}, fmt.Errorf("Invalid day end %q", cfg.DayEnd)
+		}
+		cal.DayEnd = clock
+	}
+
+	if cfg.WorkdayEnd != "" {
+		clock, ok := ParseClock(strings.ToLower(cfg.WorkdayEnd))
+		if !ok {
+			return Calendar{}, fmt.Errorf("Invalid workday end %q", cfg.WorkdayEnd)
+		}
+		cal.WorkingHours = defaultWorkingHours(clock)
+	}
+
+	if len(cfg.WorkingHours) > 0 {
+		cal.WorkingHours, err = parseWorkingHours(cfg.WorkingHours)
+		if err != nil {
+			return Calendar{}, err
+		}
+	}
+
+	for _, holiday := range cfg.Holidays {
+		if _, err := time.Parse(time.DateOnly, holiday); err != nil {
+			return Calendar{}, fmt.Errorf("Invalid holiday %q", holiday)
+		}
+		if cal.Holidays == nil {
+			cal.Holidays = map[string]bool{}
+		}
+		cal.Holidays[holiday] = true
+	}
+
+	return cal, nil
+}
+
+// Load builds the calendar from the config file.
+func Load(tz string) (Calendar, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return Calendar{}, fmt.Errorf("Failed to load config: %s", err)
+	}
+
+	return FromConfig(cfg, tz)
+}
+
+func (c Calendar) Now() time.Time {
+	return time.Now().In(c.Location)
+}
+
+// Day returns the midnight of the day t belongs to. Times before the end of
+// the day belong to the previous day.
+func (c Calendar) Day(t time.Time) time.Time {
+	t = t.In(c.Location)
+	day := Clock{}.On(t)
+	if t.Before(c.DayEnd.On(t)) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+func (c Calendar) StartOfDay(day time.Time) time.Time {
+	return c.DayEnd.On(day)
+}
+
+func (c Calendar) EndOfDay(day time.Time) time.Time {
+	return c.StartOfDay(day.AddDate(0, 0, 1))
+}
+
+func (c Calendar) StartOfWeek(t time.Time) time.Time {
+	day := c.Day(t)
+	return c.StartOfDay(day.AddDate(0, 0, DaysFromStartOfDayUntilEndOfWeek(day, c.WeekStart)-7))
+}
+
+func (c Calendar) EndOfWeek(t time.Time) time.Time {
+	day := c.Day(t)
+	return c.EndOfDay(day.AddDate(0, 0, DaysFromStartOfDayUntilEndOfWeek(day, c.WeekStart)-1))
+}
+
+// Next returns the start of the next given weekday after the day of t.
+func (c Calendar) Next(t time.Time, weekday time.Weekday) time.Time {
+	day := c.Day(t)
+	days := (int(weekday)-int(day.Weekday())+6)%7 + 1
+	return c.StartOfDay(day.AddDate(0, 0, days))
+}
+
+func (c Calendar) Format(timeout int64) string {
+	return time.Unix(timeout, 0).In(c.Location).Format("Mon, 02 Jan 2006 15:04 MST")
+}
+
+func DaysFromStartOfDayUntilEndOfWeek(date time.Time, weekStart time.Weekday) int {
+	weekEnd := (int(weekStart) + 6) % 7
+	return (weekEnd-int(date.Weekday())+7)%7 + 1
+}
+
+func ParseWeekday(token string) (time.Weekday, bool) {
+	for name, weekday := range weekdays {
+		if token == name || token == name[:3] {
+			return weekday, true
+		}
+	}
+
+	return 0, false
+}
+
+// ParseClock parses clock times like "17:30", "5pm" or "9:15am".
+func ParseClock(token string) (Clock, bool) {
+	match := clockRegexp.FindStringSubmatch(token)
+	if match == nil {
+		return Clock{}, false
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	} else if match[3] == "" {
+		// A bare number is not a clock time.
+		return Clock{}, false
+	}
+
+	switch match[3] {
+	case "am":
+		if hour < 1 || hour > 12 {
+			return Clock{}, false
+		}
+		hour %= 12
+	case "pm":
+		if hour < 1 || hour > 12 {
+			return Clock{}, false
+		}
+		hour = hour%12 + 12
+	}
+
+	if hour > 23 || minute > 59 {
+		return Clock{}, false
+	}
+
+	return Clock{Hour: hour, Minute: minute}, true
+}
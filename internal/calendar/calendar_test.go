@@ -0,0 +1,50 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaysFromStartOfDayUntilEndOfWeek(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	// Sunday
+	start := time.Date(2024, 6, 2, 18, 7, 0, 0, loc)
+
+	tests := []struct {
+		weekStart time.Weekday
+		expected  [7]int
+	}{
+		{time.Monday, [7]int{1, 7, 6, 5, 4, 3, 2}},
+		{time.Sunday, [7]int{7, 6, 5, 4, 3, 2, 1}},
+		{time.Saturday, [7]int{6, 5, 4, 3, 2, 1, 7}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.weekStart.String(), func(t *testing.T) {
+			for i, expected := range test.expected {
+				date := start.AddDate(0, 0, i)
+				assert.Equal(t, expected, DaysFromStartOfDayUntilEndOfWeek(date, test.weekStart), date.Weekday().String())
+			}
+		})
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	cal := Default()
+	cal.Location = time.UTC
+	cal.DayEnd = Clock{Hour: 6}
+
+	monday := time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)
+	assert.Equal(t, monday, cal.StartOfWeek(monday))
+	assert.Equal(t, monday, cal.StartOfWeek(time.Date(2026, 10, 25, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, monday, cal.StartOfWeek(time.Date(2026, 10, 26, 5, 0, 0, 0, time.UTC)))
+
+	cal.WeekStart = time.Sunday
+	assert.Equal(t, monday.AddDate(0, 0, -1), cal.StartOfWeek(monday))
+}
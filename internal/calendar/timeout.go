@@ -0,0 +1,299 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PresetNever        = "never"
+	Preset30Minutes    = "30 minutes"
+	Preset1Hour        = "1 hour"
+	Preset4Hours       = "4 hours"
+	PresetEndOfWorkday = "end of workday"
+	PresetNextWorkday  = "until next workday"
+	PresetToday        = "today"
+	PresetThisWeek     = "this week"
+)
+
+var (
+	relativeTimeoutRegexp = regexp.MustCompile(`^(\d+)\s*(m|mins?|minutes?|h|hours?|d|days?)$`)
+
+	absoluteTimeoutLayouts = []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+	}
+)
+
+type Preset struct {
+	Label string
+
+	// Timeout is the unix timestamp at which the status is deleted or 0 if
+	// it is never deleted.
+	Timeout int64
+}
+
+// Presets returns the predefined timeouts relative to now. Presets based on
+// working hours are omitted if there are none.
+func (c Calendar) Presets(now time.Time) []Preset {
+	now = now.In(c.Location)
+	presets := []Preset{
+		{PresetNever, 0},
+		{Preset30Minutes, now.Add(30 * time.Minute).Unix()},
+		{Preset1Hour, now.Add(time.Hour).Unix()},
+		{Preset4Hours, now.Add(4 * time.Hour).Unix()},
+	}
+
+	if endOfWorkday := c.EndOfWorkday(now); !endOfWorkday.IsZero() {
+		presets = append(presets, Preset{PresetEndOfWorkday, endOfWorkday.Unix()})
+	}
+	if nextWorkdayStart := c.NextWorkdayStart(now); !nextWorkdayStart.IsZero() {
+		presets = append(presets, Preset{PresetNextWorkday, nextWorkdayStart.Unix()})
+	}
+
+	weekStart := strings.ToLower(c.WeekStart.String())
+	return append(presets,
+		Preset{PresetToday, c.EndOfDay(c.Day(now)).Unix()},
+		Preset{PresetThisWeek, c.EndOfWeek(now).Unix()},
+		Preset{"next " + weekStart, c.Next(now, c.WeekStart).Unix()},
+	)
+}
+
+// ParseTimeout resolves a timeout expression to a unix timestamp relative to
+// now. A timestamp of 0 means that the status is never deleted.
+//
+// Besides the presets, durations ("90m", "2h30m", "2 hours"), clock times
+// ("17:30", "until 9am tomorrow"), days ("tomorrow", "friday 16:00", "next
+// monday"), dates ("2026-10-20") and timestamps (ISO 8601 and RFC 3339) are
+// supported. Days and dates without a clock time last until their end.
+func (c Calendar) ParseTimeout(expr string, now time.Time) (int64, error) {
+	now = now.In(c.Location)
+	expr = strings.Join(strings.Fields(strings.ToLower(expr)), " ")
+	for _, preset := range c.Presets(now) {
+		if preset.Label == expr {
+			return preset.Timeout, nil
+		}
+	}
+
+	at, err := c.parseTimeoutTime(expr, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if !at.After(now) {
+		return 0, fmt.Errorf("Timeout %q lies in the past (%s)", expr, at.Format(time.DateTime))
+	}
+
+	return at.Unix(), nil
+}
+
+// ParseTime resolves an expression to a point in time after now, e.g. for
+// scheduling a status change.
+//
+// Durations ("30m", "in 2 hours"), clock times ("12:00", "9am tomorrow"),
+// days ("friday 16:00"), dates ("2026-10-20") and timestamps (ISO 8601 and
+// RFC 3339) are supported. Days and dates without a clock time start at their
+// beginning.
+func (c Calendar) ParseTime(expr string, now time.Time) (time.Time, error) {
+	now = now.In(c.Location)
+	expr = strings.Join(strings.Fields(strings.ToLower(expr)), " ")
+
+	at, err := c.parseTime(expr, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("Time %q lies in the past (%s)", expr, at.Format(time.DateTime))
+	}
+
+	return at, nil
+}
+
+func (c Calendar) parseTime(expr string, now time.Time) (time.Time, error) {
+	if at, ok := c.parseTimestamp(expr); ok {
+		return at, nil
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, expr, c.Location); err == nil {
+		return c.StartOfDay(date), nil
+	}
+
+	if at, ok := parseDuration(strings.TrimPrefix(expr, "in "), now); ok {
+		return at, nil
+	}
+
+	at, err := c.parseDayAndClock(expr, now, c.StartOfDay)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time %q", expr)
+	}
+	return at, nil
+}
+
+func (c Calendar) parseTimeoutTime(expr string, now time.Time) (time.Time, error) {
+	if at, ok := c.parseTimestamp(expr); ok {
+		return at, nil
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, expr, c.Location); err == nil {
+		return c.EndOfDay(date), nil
+	}
+
+	for _, prefix := range []string{"until ", "in ", "for "} {
+		expr = strings.TrimPrefix(expr, prefix)
+	}
+
+	switch expr {
+	case "end of day", "eod":
+		return c.EndOfDay(c.Day(now)), nil
+	case "end of week", "eow", "next week":
+		return c.EndOfWeek(now), nil
+	case PresetEndOfWorkday:
+		if at := c.EndOfWorkday(now); !at.IsZero() {
+			return at, nil
+		}
+		return time.Time{}, errors.New("No working hours are configured")
+	case "next workday":
+		if at := c.NextWorkdayStart(now); !at.IsZero() {
+			return at, nil
+		}
+		return time.Time{}, errors.New("No working hours are configured")
+	}
+
+	if name, ok := strings.CutPrefix(expr, "next "); ok {
+		if weekday, ok := ParseWeekday(name); ok {
+			return c.Next(now, weekday), nil
+		}
+	}
+
+	if at, ok := parseDuration(expr, now); ok {
+		return at, nil
+	}
+
+	at, err := c.parseDayAndClock(expr, now, c.EndOfDay)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid timeout %q", expr)
+	}
+	return at, nil
+}
+
+// parseTimestamp parses RFC 3339 and ISO 8601 timestamps. Timestamps without
+// a zone are interpreted in the calendar's location.
+func (c Calendar) parseTimestamp(expr string) (time.Time, bool) {
+	if at, err := time.Parse(time.RFC3339, strings.ToUpper(expr)); err == nil {
+		return at, true
+	}
+
+	for _, layout := range absoluteTimeoutLayouts {
+		if at, err := time.ParseInLocation(layout, strings.ToUpper(expr), c.Location); err == nil {
+			return at, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseDuration parses durations like "2h30m" or "2 hours" relative to now.
+func parseDuration(expr string, now time.Time) (time.Time, bool) {
+	if duration, err := time.ParseDuration(expr); err == nil && duration > 0 {
+		return now.Add(duration), true
+	}
+
+	if match := relativeTimeoutRegexp.FindStringSubmatch(expr); match != nil {
+		amount, _ := strconv.Atoi(match[1])
+		switch match[2][0] {
+		case 'm':
+			return now.Add(time.Duration(amount) * time.Minute), true
+		case 'h':
+			return now.Add(time.Duration(amount) * time.Hour), true
+		default:
+			return now.AddDate(0, 0, amount), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseDayAndClock parses expressions like "17:30", "9am tomorrow" or
+// "friday 16:00". Days without a clock time are resolved with wholeDay.
+func (c Calendar) parseDayAndClock(expr string, now time.Time, wholeDay func(day time.Time) time.Time) (time.Time, error) {
+	invalid := errors.New("Invalid day or clock time")
+
+	expr = strings.NewReplacer(" am", "am", " pm", "pm").Replace(expr)
+	var day *time.Time
+	var clock *Clock
+	isWeekday := false
+	for _, token := range strings.Fields(expr) {
+		if token == "at" || token == "on" {
+			continue
+		}
+
+		if date, weekday, ok := c.parseDay(token, now); ok && day == nil {
+			day = &date
+			isWeekday = weekday
+			continue
+		}
+
+		if parsed, ok := ParseClock(token); ok && clock == nil {
+			clock = &parsed
+			continue
+		}
+
+		return time.Time{}, invalid
+	}
+
+	if day == nil && clock == nil {
+		return time.Time{}, invalid
+	}
+
+	if clock == nil {
+		at := wholeDay(*day)
+		if isWeekday && !at.After(now) {
+			at = at.AddDate(0, 0, 7)
+		}
+		return at, nil
+	}
+
+	if day == nil {
+		at := clock.On(now)
+		if !at.After(now) {
+			at = clock.On(now.AddDate(0, 0, 1))
+		}
+		return at, nil
+	}
+
+	// Clock times before the end of a day belong to the following night.
+	at := clock.On(*day)
+	if at.Before(c.StartOfDay(*day)) {
+		at = clock.On(day.AddDate(0, 0, 1))
+	}
+	if isWeekday && !at.After(now) {
+		at = at.AddDate(0, 0, 7)
+	}
+	return at, nil
+}
+
+// parseDay resolves "today", "tomorrow" and weekday names to the midnight of
+// the next matching day including today.
+func (c Calendar) parseDay(token string, now time.Time) (day time.Time, isWeekday bool, ok bool) {
+	today := c.Day(now)
+	switch token {
+	case "today":
+		return today, false, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), false, true
+	}
+
+	if weekday, ok := ParseWeekday(token); ok {
+		days := (int(weekday) - int(today.Weekday()) + 7) % 7
+		return today.AddDate(0, 0, days), true, true
+	}
+
+	return time.Time{}, false, false
+}
@@ -0,0 +1,281 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeout(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	// Wednesday
+	now := time.Date(2024, 6, 5, 18, 7, 0, 0, loc)
+	cal := Default()
+	cal.Location = loc
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"never", time.Unix(0, 0)},
+		{"1 hour", now.Add(time.Hour)},
+		{"This Week", time.Date(2024, 6, 10, 0, 0, 0, 0, loc)},
+		{"90m", now.Add(90 * time.Minute)},
+		{"2h30m", now.Add(150 * time.Minute)},
+		{"in 2 hours", now.Add(2 * time.Hour)},
+		{"for 3 days", time.Date(2024, 6, 8, 18, 7, 0, 0, loc)},
+		{"19:30", time.Date(2024, 6, 5, 19, 30, 0, 0, loc)},
+		{"17:30", time.Date(2024, 6, 6, 17, 30, 0, 0, loc)},
+		{"until 9am tomorrow", time.Date(2024, 6, 6, 9, 0, 0, 0, loc)},
+		{"tomorrow at 5:15 pm", time.Date(2024, 6, 6, 17, 15, 0, 0, loc)},
+		{"12pm", time.Date(2024, 6, 6, 12, 0, 0, 0, loc)},
+		{"12am", time.Date(2024, 6, 6, 0, 0, 0, 0, loc)},
+		{"tomorrow", time.Date(2024, 6, 7, 0, 0, 0, 0, loc)},
+		{"friday", time.Date(2024, 6, 8, 0, 0, 0, 0, loc)},
+		{"wednesday", time.Date(2024, 6, 6, 0, 0, 0, 0, loc)},
+		{"wed 9:00", time.Date(2024, 6, 12, 9, 0, 0, 0, loc)},
+		{"on mon at 8am", time.Date(2024, 6, 10, 8, 0, 0, 0, loc)},
+		{"2024-06-20", time.Date(2024, 6, 21, 0, 0, 0, 0, loc)},
+		{"2024-06-20 12:00", time.Date(2024, 6, 20, 12, 0, 0, 0, loc)},
+		{"2024-06-20T12:00:30", time.Date(2024, 6, 20, 12, 0, 30, 0, loc)},
+		{"2024-06-20T12:00:00Z", time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC)},
+		{"2024-06-20T12:00:00+09:00", time.Date(2024, 6, 20, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			timeout, err := cal.ParseTimeout(test.expr, now)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected.Unix(), timeout)
+		})
+	}
+}
+
+func TestParseTimeoutErrors(t *testing.T) {
+	now := time.Date(2024, 6, 5, 18, 7, 0, 0, time.UTC)
+	cal := Default()
+	cal.Location = time.UTC
+	cal.WorkingHours = nil
+
+	for _, expr := range []string{
+		"",
+		"soon",
+		"25:00",
+		"13pm",
+		"42",
+		"-1h",
+		"today 9:00",
+		"2024-06-01",
+		"2024-06-05T12:00:00Z",
+		"friday tomorrow",
+		"end of workday",
+		"until end of workday",
+		"next workday",
+		"until next workday",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := cal.ParseTimeout(expr, now)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	// Wednesday
+	now := time.Date(2024, 6, 5, 18, 7, 0, 0, loc)
+	cal := Default()
+	cal.Location = loc
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"90m", now.Add(90 * time.Minute)},
+		{"in 2 hours", now.Add(2 * time.Hour)},
+		{"19:30", time.Date(2024, 6, 5, 19, 30, 0, 0, loc)},
+		{"17:30", time.Date(2024, 6, 6, 17, 30, 0, 0, loc)},
+		{"tomorrow 9am", time.Date(2024, 6, 6, 9, 0, 0, 0, loc)},
+		{"tomorrow", time.Date(2024, 6, 6, 0, 0, 0, 0, loc)},
+		{"friday", time.Date(2024, 6, 7, 0, 0, 0, 0, loc)},
+		{"wednesday", time.Date(2024, 6, 12, 0, 0, 0, 0, loc)},
+		{"on mon at 8am", time.Date(2024, 6, 10, 8, 0, 0, 0, loc)},
+		{"2024-06-20", time.Date(2024, 6, 20, 0, 0, 0, 0, loc)},
+		{"2024-06-20 12:00", time.Date(2024, 6, 20, 12, 0, 0, 0, loc)},
+		{"2024-06-20T12:00:00Z", time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			at, err := cal.ParseTime(test.expr, now)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected.Unix(), at.Unix())
+		})
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	now := time.Date(2024, 6, 5, 18, 7, 0, 0, time.UTC)
+	cal := Default()
+	cal.Location = time.UTC
+
+	for _, expr := range []string{
+		"",
+		"soon",
+		"never",
+		"end of day",
+		"today",
+		"today 9:00",
+		"2024-06-05",
+		"2024-06-05T12:00:00Z",
+		"-1h",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := cal.ParseTime(expr, now)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTimeoutPresets(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name      string
+		weekStart time.Weekday
+		dayEnd    Clock
+		now       time.Time
+		expected  map[string]time.Time
+	}{
+		{
+			name:      "week starting on monday",
+			weekStart: time.Monday,
+			now:       time.Date(2024, 6, 5, 18, 7, 0, 0, loc),
+			expected: map[string]time.Time{
+				PresetEndOfWorkday: time.Date(2024, 6, 6, 17, 0, 0, 0, loc),
+				PresetToday:        time.Date(2024, 6, 6, 0, 0, 0, 0, loc),
+				PresetThisWeek:     time.Date(2024, 6, 10, 0, 0, 0, 0, loc),
+				"next monday":      time.Date(2024, 6, 10, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name:      "week starting on sunday",
+			weekStart: time.Sunday,
+			now:       time.Date(2024, 6, 5, 12, 0, 0, 0, loc),
+			expected: map[string]time.Time{
+				PresetEndOfWorkday: time.Date(2024, 6, 5, 17, 0, 0, 0, loc),
+				PresetThisWeek:     time.Date(2024, 6, 9, 0, 0, 0, 0, loc),
+				"next sunday":      time.Date(2024, 6, 9, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name:      "week starting on saturday on a friday",
+			weekStart: time.Saturday,
+			now:       time.Date(2024, 6, 7, 12, 0, 0, 0, loc),
+			expected: map[string]time.Time{
+				PresetThisWeek:  time.Date(2024, 6, 8, 0, 0, 0, 0, loc),
+				"next saturday": time.Date(2024, 6, 8, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name:      "day ending at 06:00 before midnight",
+			weekStart: time.Monday,
+			dayEnd:    Clock{Hour: 6},
+			now:       time.Date(2024, 6, 9, 22, 0, 0, 0, loc),
+			expected: map[string]time.Time{
+				PresetToday:    time.Date(2024, 6, 10, 6, 0, 0, 0, loc),
+				PresetThisWeek: time.Date(2024, 6, 10, 6, 0, 0, 0, loc),
+				"next monday":  time.Date(2024, 6, 10, 6, 0, 0, 0, loc),
+			},
+		},
+		{
+			name:      "day ending at 06:00 after midnight",
+			weekStart: time.Monday,
+			dayEnd:    Clock{Hour: 6},
+			now:       time.Date(2024, 6, 10, 2, 0, 0, 0, loc),
+			expected: map[string]time.Time{
+				PresetToday:    time.Date(2024, 6, 10, 6, 0, 0, 0, loc),
+				PresetThisWeek: time.Date(2024, 6, 10, 6, 0, 0, 0, loc),
+				"next monday":  time.Date(2024, 6, 10, 6, 0, 0, 0, loc),
+			},
+		},
+		{
+			name:      "end of daylight saving time",
+			weekStart: time.Monday,
+			now:       time.Date(2024, 10, 26, 12, 0, 0, 0, loc),
+			expected: map[string]time.Time{
+				PresetToday:    time.Date(2024, 10, 27, 0, 0, 0, 0, loc),
+				PresetThisWeek: time.Date(2024, 10, 28, 0, 0, 0, 0, loc),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cal := Default()
+			cal.Location = loc
+			cal.WeekStart = test.weekStart
+			cal.DayEnd = test.dayEnd
+
+			presets := map[string]int64{}
+			for _, preset := range cal.Presets(test.now) {
+				presets[preset.Label] = preset.Timeout
+			}
+
+			for key, expected := range test.expected {
+				assert.Equal(t, expected.Unix(), presets[key], key)
+			}
+		})
+	}
+}
+
+func TestParseTimeoutWithCalendar(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		panic(err)
+	}
+
+	cal := Default()
+	cal.Location = tokyo
+	cal.DayEnd = Clock{Hour: 6}
+
+	// Friday 23:00 in Tokyo
+	now := time.Date(2024, 6, 7, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"today", time.Date(2024, 6, 8, 6, 0, 0, 0, tokyo)},
+		{"today 3am", time.Date(2024, 6, 8, 3, 0, 0, 0, tokyo)},
+		{"tomorrow", time.Date(2024, 6, 9, 6, 0, 0, 0, tokyo)},
+		{"friday", time.Date(2024, 6, 8, 6, 0, 0, 0, tokyo)},
+		{"next friday", time.Date(2024, 6, 14, 6, 0, 0, 0, tokyo)},
+		{"next week", time.Date(2024, 6, 10, 6, 0, 0, 0, tokyo)},
+		{"2024-06-10 09:00", time.Date(2024, 6, 10, 9, 0, 0, 0, tokyo)},
+		{"end of workday", time.Date(2024, 6, 10, 17, 0, 0, 0, tokyo)},
+		{"until next workday", time.Date(2024, 6, 10, 9, 0, 0, 0, tokyo)},
+		{"until end of workday", time.Date(2024, 6, 10, 17, 0, 0, 0, tokyo)},
+		{"next workday", time.Date(2024, 6, 10, 9, 0, 0, 0, tokyo)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			timeout, err := cal.ParseTimeout(test.expr, now)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected.Unix(), timeout)
+		})
+	}
+}
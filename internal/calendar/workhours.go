@@ -0,0 +1,131 @@
+package calendar
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxDaysOff bounds the search for the next workday across weekends and
+// holidays.
+const maxDaysOff = 366
+
+type Hours struct {
+	Start Clock
+	End   Clock
+}
+
+func (h Hours) String() string {
+	return fmt.Sprintf("%s-%s", h.Start, h.End)
+}
+
+func defaultWorkingHours(end Clock) map[time.Weekday]Hours {
+	hours := map[time.Weekday]Hours{}
+	for weekday := time.Monday; weekday <= time.Friday; weekday++ {
+		hours[weekday] = Hours{Start: Clock{Hour: 9}, End: end}
+	}
+	return hours
+}
+
+// parseWorkingHours parses working hours like {"mon-fri": "09:00-17:00",
+// "fri": "9am-1pm"}. Single days take precedence over ranges of days, other
+// overlapping days are rejected.
+func parseWorkingHours(config map[string]string) (map[time.Weekday]Hours, error) {
+	// Ranges are applied before single days, each in a stable order so
+	// overlaps are reported consistently.
+	keys := slices.Sorted(maps.Keys(config))
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return cmp.Compare(strings.Count(b, "-"), strings.Count(a, "-"))
+	})
+
+	workingHours := map[time.Weekday]Hours{}
+	setBy := map[time.Weekday]string{}
+	for _, days := range keys {
+		hours := config[days]
+		first, last, isRange := strings.Cut(strings.ToLower(days), "-")
+		firstDay, ok := ParseWeekday(strings.TrimSpace(first))
+		lastDay := firstDay
+		if ok && isRange {
+			lastDay, ok = ParseWeekday(strings.TrimSpace(last))
+		}
+		if !ok {
+			return nil, fmt.Errorf("Invalid working days %q", days)
+		}
+
+		start, end, _ := strings.Cut(strings.ToLower(hours), "-")
+		startClock, ok := ParseClock(strings.TrimSpace(start))
+		endClock, ok2 := ParseClock(strings.TrimSpace(end))
+		if !ok || !ok2 || !startClock.Before(endClock) {
+			return nil, fmt.Errorf("Invalid working hours %q", hours)
+		}
+
+		for weekday := firstDay; ; weekday = (weekday + 1) % 7 {
+			other, set := setBy[weekday]
+			if set && strings.Contains(other, "-") == isRange {
+				return nil, fmt.Errorf("Working days %q and %q overlap", other, days)
+			}
+
+			workingHours[weekday] = Hours{Start: startClock, End: endClock}
+			setBy[weekday] = days
+			if weekday == lastDay {
+				break
+			}
+		}
+	}
+
+	return workingHours, nil
+}
+
+// IsHoliday reports whether the date of t is a holiday.
+func (c Calendar) IsHoliday(t time.Time) bool {
+	return c.Holidays[t.In(c.Location).Format(time.DateOnly)]
+}
+
+// hours returns the working hours on the date of day.
+func (c Calendar) hours(day time.Time) (Hours, bool) {
+	if c.IsHoliday(day) {
+		return Hours{}, false
+	}
+
+	hours, ok := c.WorkingHours[day.Weekday()]
+	return hours, ok
+}
+
+// IsWorkingTime reports whether t lies within the working hours.
+func (c Calendar) IsWorkingTime(t time.Time) bool {
+	t = t.In(c.Location)
+	hours, ok := c.hours(t)
+	return ok && !t.Before(hours.Start.On(t)) && t.Before(hours.End.On(t))
+}
+
+// EndOfWorkday returns the end of the current workday or of the next one if
+// t lies after the working hours of today. The zero time is returned if there
+// are no working hours.
+func (c Calendar) EndOfWorkday(t time.Time) time.Time {
+	t = t.In(c.Location)
+	for i := 0; i < maxDaysOff; i++ {
+		day := t.AddDate(0, 0, i)
+		if hours, ok := c.hours(day); ok && hours.End.On(day).After(t) {
+			return hours.End.On(day)
+		}
+	}
+
+	return time.Time{}
+}
+
+// NextWorkdayStart returns the next start of the working hours after t. The
+// zero time is returned if there are no working hours.
+func (c Calendar) NextWorkdayStart(t time.Time) time.Time {
+	t = t.In(c.Location)
+	for i := 0; i < maxDaysOff; i++ {
+		day := t.AddDate(0, 0, i)
+		if hours, ok := c.hours(day); ok && hours.Start.On(day).After(t) {
+			return hours.Start.On(day)
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,199 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkingHours(t *testing.T) {
+	hours, err := parseWorkingHours(map[string]string{
+		"mon-thu": "09:00-17:00",
+		"Fri":     "9am - 1pm",
+		"sat-sun": "22:00-23:30",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[time.Weekday]Hours{
+		time.Monday:    {Clock{Hour: 9}, Clock{Hour: 17}},
+		time.Tuesday:   {Clock{Hour: 9}, Clock{Hour: 17}},
+		time.Wednesday: {Clock{Hour: 9}, Clock{Hour: 17}},
+		time.Thursday:  {Clock{Hour: 9}, Clock{Hour: 17}},
+		time.Friday:    {Clock{Hour: 9}, Clock{Hour: 13}},
+		time.Saturday:  {Clock{Hour: 22}, Clock{Hour: 23, Minute: 30}},
+		time.Sunday:    {Clock{Hour: 22}, Clock{Hour: 23, Minute: 30}},
+	}, hours)
+
+	hours, err = parseWorkingHours(map[string]string{"fri-mon": "10:00-12:00"})
+	assert.NoError(t, err)
+	assert.Len(t, hours, 4)
+	assert.Contains(t, hours, time.Sunday)
+
+	// Single days take precedence over ranges.
+	for range 10 {
+		hours, err = parseWorkingHours(map[string]string{
+			"mon-fri": "09:00-17:00",
+			"fri":     "09:00-13:00",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Hours{Clock{Hour: 9}, Clock{Hour: 17}}, hours[time.Thursday])
+		assert.Equal(t, Hours{Clock{Hour: 9}, Clock{Hour: 13}}, hours[time.Friday])
+	}
+
+	for _, config := range []map[string]string{
+		{"mon-wed": "09:00-17:00", "wed-fri": "10:00-18:00"},
+		{"fri": "09:00-17:00", "Fri": "10:00-18:00"},
+	} {
+		_, err := parseWorkingHours(config)
+		assert.Error(t, err, config)
+	}
+
+	for days, hours := range map[string]string{
+		"someday": "09:00-17:00",
+		"mon-xyz": "09:00-17:00",
+		"mon":     "17:00-09:00",
+		"tue":     "09:00",
+		"wed":     "9-17",
+	} {
+		_, err := parseWorkingHours(map[string]string{days: hours})
+		assert.Error(t, err, days)
+	}
+}
+
+func TestWorkingHours(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	cal := Default()
+	cal.Location = loc
+
+	tests := []struct {
+		name             string
+		now              time.Time
+		working          bool
+		endOfWorkday     time.Time
+		nextWorkdayStart time.Time
+	}{
+		{
+			name:             "before work",
+			now:              time.Date(2024, 6, 5, 7, 0, 0, 0, loc),
+			working:          false,
+			endOfWorkday:     time.Date(2024, 6, 5, 17, 0, 0, 0, loc),
+			nextWorkdayStart: time.Date(2024, 6, 5, 9, 0, 0, 0, loc),
+		},
+		{
+			name:             "during work",
+			now:              time.Date(2024, 6, 5, 9, 0, 0, 0, loc),
+			working:          true,
+			endOfWorkday:     time.Date(2024, 6, 5, 17, 0, 0, 0, loc),
+			nextWorkdayStart: time.Date(2024, 6, 6, 9, 0, 0, 0, loc),
+		},
+		{
+			name:             "end of work",
+			now:              time.Date(2024, 6, 5, 17, 0, 0, 0, loc),
+			working:          false,
+			endOfWorkday:     time.Date(2024, 6, 6, 17, 0, 0, 0, loc),
+			nextWorkdayStart: time.Date(2024, 6, 6, 9, 0, 0, 0, loc),
+		},
+		{
+			name:             "weekend",
+			now:              time.Date(2024, 6, 8, 12, 0, 0, 0, loc),
+			working:          false,
+			endOfWorkday:     time.Date(2024, 6, 10, 17, 0, 0, 0, loc),
+			nextWorkdayStart: time.Date(2024, 6, 10, 9, 0, 0, 0, loc),
+		},
+		{
+			name:             "weekend with start of daylight saving time",
+			now:              time.Date(2024, 3, 29, 18, 0, 0, 0, loc),
+			working:          false,
+			endOfWorkday:     time.Date(2024, 4, 1, 17, 0, 0, 0, loc),
+			nextWorkdayStart: time.Date(2024, 4, 1, 9, 0, 0, 0, loc),
+		},
+		{
+			name:             "weekend with end of daylight saving time",
+			now:              time.Date(2024, 10, 25, 18, 0, 0, 0, loc),
+			working:          false,
+			endOfWorkday:     time.Date(2024, 10, 28, 17, 0, 0, 0, loc),
+			nextWorkdayStart: time.Date(2024, 10, 28, 9, 0, 0, 0, loc),
+		},
+		{
+			name:             "other time zone",
+			now:              time.Date(2024, 6, 5, 8, 0, 0, 0, time.UTC),
+			working:          true,
+			endOfWorkday:     time.Date(2024, 6, 5, 17, 0, 0, 0, loc),
+			nextWorkdayStart: time.Date(2024, 6, 6, 9, 0, 0, 0, loc),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.working, cal.IsWorkingTime(test.now))
+			assert.Equal(t, test.endOfWorkday.Unix(), cal.EndOfWorkday(test.now).Unix())
+			assert.Equal(t, test.nextWorkdayStart.Unix(), cal.NextWorkdayStart(test.now).Unix())
+		})
+	}
+}
+
+func TestWorkingHoursAcrossDaylightSavingTimeTransitions(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	// A night shift on Sunday spanning the transitions at 02:00 and 03:00.
+	cal := Default()
+	cal.Location = loc
+	cal.WorkingHours = map[time.Weekday]Hours{
+		time.Sunday: {Start: Clock{Hour: 1}, End: Clock{Hour: 4}},
+	}
+
+	// On 2024-03-31 the clocks skip from 02:00 to 03:00, so the shift lasts
+	// two hours.
+	start := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	assert.True(t, cal.IsWorkingTime(start))
+	assert.True(t, cal.IsWorkingTime(start.Add(time.Hour+59*time.Minute)))
+	assert.False(t, cal.IsWorkingTime(start.Add(2*time.Hour)))
+	assert.Equal(t, start.Add(2*time.Hour).Unix(), cal.EndOfWorkday(start).Unix())
+
+	// On 2024-10-27 the clocks go back from 03:00 to 02:00, so the shift
+	// lasts four hours.
+	start = time.Date(2024, 10, 26, 23, 0, 0, 0, time.UTC)
+	assert.True(t, cal.IsWorkingTime(start))
+	assert.True(t, cal.IsWorkingTime(start.Add(3*time.Hour+59*time.Minute)))
+	assert.False(t, cal.IsWorkingTime(start.Add(4*time.Hour)))
+	assert.Equal(t, start.Add(4*time.Hour).Unix(), cal.EndOfWorkday(start).Unix())
+	assert.Equal(t, time.Date(2024, 11, 3, 1, 0, 0, 0, loc).Unix(), cal.NextWorkdayStart(start).Unix())
+}
+
+func TestFromConfigWorkingHours(t *testing.T) {
+	cal, err := FromConfig(config.Config{WorkdayEnd: "16:30"}, "UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, Hours{Clock{Hour: 9}, Clock{Hour: 16, Minute: 30}}, cal.WorkingHours[time.Friday])
+	assert.NotContains(t, cal.WorkingHours, time.Saturday)
+
+	cal, err = FromConfig(config.Config{WorkdayEnd: "16:30", WorkingHours: map[string]string{"sat": "10:00-14:00"}}, "UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, map[time.Weekday]Hours{time.Saturday: {Clock{Hour: 10}, Clock{Hour: 14}}}, cal.WorkingHours)
+
+	_, err = FromConfig(config.Config{WorkingHours: map[string]string{"sat": "late"}}, "UTC")
+	assert.Error(t, err)
+}
+
+func TestHolidays(t *testing.T) {
+	cal, err := FromConfig(config.Config{Holidays: []string{"2026-12-24", "2026-12-25"}}, "UTC")
+	assert.NoError(t, err)
+
+	assert.True(t, cal.IsHoliday(time.Date(2026, 12, 24, 23, 0, 0, 0, time.UTC)))
+	assert.False(t, cal.IsWorkingTime(time.Date(2026, 12, 24, 10, 0, 0, 0, time.UTC)))
+
+	// Wednesday before the holidays
+	now := time.Date(2026, 12, 23, 18, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 12, 28, 9, 0, 0, 0, time.UTC), cal.NextWorkdayStart(now))
+	assert.Equal(t, time.Date(2026, 12, 28, 17, 0, 0, 0, time.UTC), cal.EndOfWorkday(now))
+
+	_, err = FromConfig(config.Config{Holidays: []string{"christmas"}}, "UTC")
+	assert.Error(t, err)
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/st3iny/nextcloud-status-command/internal/ocs"
 )
 
-func RunAuth() error {
+func runAuth() error {
 	auth, err := ocs.LoadAuth()
 	if err != nil && !os.IsNotExist(err) {
 		fmt.Println("Warning: Failed to load existing auth data")
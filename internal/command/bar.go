@@ -0,0 +1,216 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/daemon"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const (
+	barWaybar   = "waybar"
+	barPolybar  = "polybar"
+	barI3blocks = "i3blocks"
+	barTmux     = "tmux"
+	barPrompt   = "prompt"
+)
+
+var barFormats = []string{barWaybar, barPolybar, barI3blocks, barTmux, barPrompt}
+
+// barCycle is the order in which clicks cycle through the statuses.
+var barCycle = []string{statusOnline, statusAway, statusDnd, statusInvisible}
+
+const barDefaultColor = "#b4b4b4"
+
+func setupBar(flags *flag.FlagSet) func(args []string) error {
+	format := flags.String("format", barPrompt, fmt.Sprintf("protocol of your bar [options: %s]", strings.Join(barFormats, ", ")))
+	follow := flags.Bool("follow", false, "print your status again on every change")
+	interval := flags.Duration("interval", 30*time.Second, "interval between polls with --follow while the daemon is not running")
+	maxAge := flags.Duration("max-age", time.Minute, "age after which the cached status is fetched again")
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+		if !slices.Contains(barFormats, *format) {
+			return usageError("invalid format %q", *format)
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		// i3blocks runs the command again on clicks and passes the button.
+		if *format == barI3blocks {
+			switch os.Getenv("BLOCK_BUTTON") {
+			case "1":
+				err = cycleStatus(auth)
+			case "3":
+				err = clearStatusQuietly(auth)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if *follow {
+			return followBar(auth, *format, *interval)
+		}
+
+		status, err := cachedStatus(auth, *maxAge)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(renderBar(*format, status, false))
+		return nil
+	}
+}
+
+// followBar prints the status on every change. Changes are pushed by the
+// daemon or polled if it is not running.
+func followBar(auth ocs.Auth, format string, interval time.Duration) error {
+	if client, err := daemon.Dial(); err == nil {
+		defer client.Close()
+		return client.Subscribe(func(status *ocs.UserStatus) {
+			fmt.Print(renderBar(format, status, true))
+		})
+	}
+
+	last := ""
+	for {
+		status, err := cachedStatus(auth, interval)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		} else if output := renderBar(format, status, true); output != last {
+			fmt.Print(output)
+			last = output
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func renderBar(format string, status *ocs.UserStatus, follow bool) string {
+	if status == nil {
+		status = &ocs.UserStatus{Status: statusOnline}
+	}
+
+	text := strings.TrimSpace(status.Icon + " " + status.Message)
+	if text == "" {
+		text = status.Status
+	}
+
+	color := barDefaultColor
+	if c, ok := statusColors[status.Status]; ok {
+		color = string(c)
+	}
+
+	switch format {
+	case barWaybar:
+		tooltip := status.Status
+		if status.Icon != "" || status.Message != "" {
+			tooltip += ": " + strings.TrimSpace(status.Icon+" "+status.Message)
+		}
+		if status.ClearAt > 0 {
+			tooltip += "\nCleared at " + time.Unix(status.ClearAt, 0).Format("Mon 15:04")
+		}
+
+		output, _ := json.Marshal(map[string]string{
+			"text":    text,
+			"tooltip": tooltip,
+			"class":   status.Status,
+			"alt":     status.Status,
+		})
+		return string(output) + "\n"
+	case barPolybar:
+		command := strings.ReplaceAll(barCommand(), ":", `\:`)
+		return fmt.Sprintf("%%{A1:%s cycle:}%%{A3:%s clear:}%%{F%s}●%%{F-} %s%%{A}%%{A}\n", command, command, color, text)
+	case barI3blocks:
+		// In persistent mode every line replaces the full text.
+		if follow {
+			return text + "\n"
+		}
+
+		short := status.Icon
+		if short == "" {
+			short = status.Status
+		}
+		return fmt.Sprintf("%s\n%s\n%s\n", text, short, color)
+	case barTmux:
+		return fmt.Sprintf("#[fg=%s]●#[default] %s\n", color, strings.ReplaceAll(text, "#", "##"))
+	default:
+		return text + "\n"
+	}
+}
+
+// barCommand returns the command running the bar actions of the current
+// profile.
+func barCommand() string {
+	command := "nsc"
+	if profile := ocs.Profile(); profile != "" {
+		command += " --profile " + profile
+	}
+	return command + " bar"
+}
+
+func setupBarCycle(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+		return cycleStatus(auth)
+	}
+}
+
+func setupBarClear(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+		return clearStatusQuietly(auth)
+	}
+}
+
+// cycleStatus switches to the next status and keeps the message.
+func cycleStatus(auth ocs.Auth) error {
+	status, err := getStatus(auth)
+	if err != nil {
+		return fmt.Errorf("Failed to get your current status: %s", err)
+	}
+	if status == nil {
+		status = &ocs.UserStatus{User: auth.User, Status: statusOnline}
+	}
+
+	next := *status
+	next.Status = barCycle[(slices.Index(barCycle, status.Status)+1)%len(barCycle)]
+	if err := updateStatus(auth, next.Status, next.Message, next.Icon, next.ClearAt, false); err != nil {
+		return fmt.Errorf("Failed to update your status: %s", err)
+	}
+	return nil
+}
+
+// clearStatusQuietly clears the status message without a spinner, e.g. when
+// run by a bar.
+func clearStatusQuietly(auth ocs.Auth) error {
+	if err := clearStatus(auth); err != nil {
+		return fmt.Errorf("Failed to clear your status message: %s", err)
+	}
+	return nil
+}
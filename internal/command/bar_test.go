@@ -0,0 +1,68 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/cache"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderBar(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("NSC_PROFILE", "work")
+
+	status := &ocs.UserStatus{Status: statusDnd, Icon: "📅", Message: "In a meeting"}
+	assert.JSONEq(`{"text": "📅 In a meeting", "tooltip": "dnd: 📅 In a meeting", "class": "dnd", "alt": "dnd"}`, renderBar(barWaybar, status, false))
+	assert.Equal("%{A1:nsc --profile work bar cycle:}%{A3:nsc --profile work bar clear:}%{F#ed484c}●%{F-} 📅 In a meeting%{A}%{A}\n", renderBar(barPolybar, status, false))
+	assert.Equal("📅 In a meeting\n📅\n#ed484c\n", renderBar(barI3blocks, status, false))
+	assert.Equal("📅 In a meeting\n", renderBar(barI3blocks, status, true))
+	assert.Equal("#[fg=#ed484c]●#[default] 📅 In a meeting\n", renderBar(barTmux, status, false))
+	assert.Equal("📅 In a meeting\n", renderBar(barPrompt, status, false))
+
+	// The status is shown if there is no message.
+	assert.Equal("away\n", renderBar(barPrompt, &ocs.UserStatus{Status: statusAway}, false))
+	assert.Equal("online\n", renderBar(barPrompt, nil, false))
+	assert.Equal("#[fg=#b4b4b4]●#[default] ##1\n", renderBar(barTmux, &ocs.UserStatus{Status: statusOffline, Message: "#1"}, false))
+}
+
+func TestCachedStatus(t *testing.T) {
+	t.Setenv("NSC_PROFILE", "")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	xdg.Reload()
+	defer xdg.Reload()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"ocs": {"data": {"status": "away", "message": "Lunch", "icon": "🍔", "clearAt": null}}}`))
+	}))
+	defer server.Close()
+	auth := ocs.Auth{ServerBaseUrl: server.URL, User: "alice"}
+
+	store, err := cache.DefaultStore()
+	require.NoError(t, err)
+	require.NoError(t, store.Save(&ocs.UserStatus{User: "alice", Status: statusDnd}, time.Now().Add(-30*time.Second)))
+
+	status, err := cachedStatus(auth, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, statusDnd, status.Status)
+	assert.Equal(t, 0, requests)
+
+	// Stale statuses are fetched and cached again.
+	status, err = cachedStatus(auth, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, &ocs.UserStatus{User: "alice", Status: statusAway, Icon: "🍔", Message: "Lunch"}, status)
+	assert.Equal(t, 1, requests)
+
+	entry, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, status, entry.Status)
+	assert.WithinDuration(t, time.Now(), entry.FetchedAt, time.Second)
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/meeting"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+func setupCalendar(flags *flag.FlagSet) func(args []string) error {
+	days := flags.Int("days", 1, "number of days to print meetings for")
+	return func(args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("Failed to load config: %s", err)
+		}
+
+		if len(cfg.Calendars) == 0 {
+			fmt.Println("No calendars are configured")
+			return nil
+		}
+
+		var auth *ocs.Auth
+		if a, err := ocs.LoadAuth(); err == nil {
+			auth = &a
+		}
+
+		sources, err := loadCalendars(cfg, auth)
+		if err != nil {
+			return err
+		}
+
+		cal, err := calendar.FromConfig(cfg, "")
+		if err != nil {
+			return err
+		}
+
+		now := cal.Now()
+		meetings, err := meeting.Upcoming(sources, now, now.AddDate(0, 0, *days))
+		if err != nil {
+			return err
+		}
+
+		if len(meetings) == 0 {
+			fmt.Println("No upcoming meetings")
+			return nil
+		}
+
+		for _, m := range meetings {
+			fmt.Printf("%s - %s: %s (%s)\n", cal.Format(m.Start.Unix()), m.End.In(cal.Location).Format("15:04"), m.Summary, m.Calendar)
+		}
+
+		return nil
+	}
+}
+
+// loadCalendars returns the calendars of the config. Calendars on the server
+// of auth are fetched with its credentials.
+func loadCalendars(cfg config.Config, auth *ocs.Auth) ([]meeting.Source, error) {
+	cal, err := calendar.FromConfig(cfg, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return meeting.Load(cfg, cal, auth)
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"flag"
 	"fmt"
 
 	"github.com/charmbracelet/huh/spinner"
@@ -11,22 +12,33 @@ type errorMsg struct {
 	err error
 }
 
-func RunClear() error {
-	auth, err := ocs.LoadAuth()
-	if err != nil {
-		return fmt.Errorf("Failed to load auth: %s", err)
-	}
+func setupClear(flags *flag.FlagSet) func(args []string) error {
+	queueEnabled := addQueueFlag(flags)
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
 
-	errChan := make(chan error, 1)
-	err = spinner.New().
-		Title("Clearing your status message ...").
-		Action(func() {
-			errChan <- ocs.ClearStatusMessage(auth)
-		}).
-		Run()
-	if err != nil {
-		return fmt.Errorf("Failed to render spinner: %s", err)
-	}
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return fmt.Errorf("Failed to load auth: %s", err)
+		}
 
-	return <-errChan
+		errChan := make(chan error, 1)
+		err = spinner.New().
+			Title("Clearing your status message ...").
+			Action(func() {
+				errChan <- clearStatus(auth)
+			}).
+			Run()
+		if err != nil {
+			return fmt.Errorf("Failed to render spinner: %s", err)
+		}
+
+		err = <-errChan
+		if err != nil && queueEnabled() {
+			return queueChange(err, true, ocs.UserStatus{User: auth.User})
+		}
+		return err
+	}
 }
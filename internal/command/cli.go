@@ -0,0 +1,408 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+// Version is printed by --version. It can be set at build time with
+// -ldflags "-X github.com/st3iny/nextcloud-status-command/internal/command.Version=1.0.0"
+// and defaults to the version of the module.
+var Version = ""
+
+const (
+	outputText = "text"
+	outputJson = "json"
+)
+
+var outputFormats = []string{outputText, outputJson, outputYaml, outputTsv, outputCsv, outputEnv}
+
+// Command is a subcommand of nsc.
+type Command struct {
+	Name string
+
+	// Args describes the positional arguments in the usage line, e.g.
+	// "<user>".
+	Args    string
+	Summary string
+
+	// Setup defines the flags of the command and returns the function that
+	// runs it with the positional arguments once the flags are parsed.
+	Setup func(flags *flag.FlagSet) func(args []string) error
+
+	// Subcommands are selected by the first argument. The command itself runs
+	// if none matches.
+	Subcommands []*Command
+
+	// Values complete the values of the flags by their name and ArgValues the
+	// positional arguments.
+	Values    map[string]completer
+	ArgValues argCompleter
+}
+
+// Globals are the flags accepted by every command.
+type Globals struct {
+	Profile string
+	Debug   bool
+	Output  string
+	NoColor bool
+	Version bool
+}
+
+var globals Globals
+
+func addGlobalFlags(flags *flag.FlagSet) {
+	flags.StringVar(&globals.Profile, "profile", os.Getenv(ocs.ProfileEnv), "profile to use, overrides $"+ocs.ProfileEnv)
+	flags.BoolVar(&globals.Debug, "debug", false, "log requests to the server")
+	flags.StringVar(&globals.Output, "output", outputText, fmt.Sprintf("output format [options: %s]", strings.Join(outputFormats, ", ")))
+	flags.BoolVar(&globals.NoColor, "no-color", os.Getenv("NO_COLOR") != "", "disable colors")
+	flags.BoolVar(&globals.Version, "version", false, "print the version and exit")
+}
+
+// UsageError is printed with the usage of the command.
+type UsageError struct {
+	Message string
+}
+
+func (e UsageError) Error() string {
+	return e.Message
+}
+
+func usageError(format string, args ...any) error {
+	return UsageError{Message: fmt.Sprintf(format, args...)}
+}
+
+// checkPositive rejects durations of flags that must be greater than zero.
+func checkPositive(name string, value time.Duration) error {
+	if value <= 0 {
+		return usageError("invalid value %s for --%s, expected a positive duration", value, name)
+	}
+	return nil
+}
+
+// invocation is a command with its parsed arguments.
+type invocation struct {
+	path       []*Command
+	flags      *flag.FlagSet
+	run        func(args []string) error
+	positional []string
+}
+
+func (inv invocation) command() *Command {
+	return inv.path[len(inv.path)-1]
+}
+
+func (inv invocation) name() string {
+	names := []string{"nsc"}
+	for _, cmd := range inv.path {
+		names = append(names, cmd.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+// findCommand returns the command named by the first argument that is not a
+// global flag and the remaining arguments.
+func findCommand(commands []*Command, args []string) (*Command, []string) {
+	globalFlags := flag.NewFlagSet("nsc", flag.ContinueOnError)
+	addGlobalFlags(globalFlags)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return nil, args
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			j := slices.IndexFunc(commands, func(cmd *Command) bool { return cmd.Name == arg })
+			if j < 0 {
+				return nil, args
+			}
+			return commands[j], slices.Delete(slices.Clone(args), i, i+1)
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := globalFlags.Lookup(name)
+		if f == nil {
+			return nil, args
+		}
+
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && boolFlag.IsBoolFlag()) {
+			// Skip the value of the flag.
+			i++
+		}
+	}
+
+	return nil, args
+}
+
+// findPath returns the command and its parent commands named by the
+// arguments and the remaining arguments. The update command is returned if no
+// other command is given.
+func findPath(args []string) ([]*Command, []string) {
+	cmd, rest := findCommand(commands, args)
+	if cmd == nil {
+		cmd = commands[0]
+	}
+
+	path := []*Command{cmd}
+	for len(cmd.Subcommands) > 0 {
+		sub, subRest := findCommand(cmd.Subcommands, rest)
+		if sub == nil {
+			break
+		}
+		cmd, rest = sub, subRest
+		path = append(path, cmd)
+	}
+
+	return path, rest
+}
+
+// setup returns an invocation of the command with its flags defined.
+func setup(path []*Command) invocation {
+	inv := invocation{path: path}
+	inv.flags = flag.NewFlagSet(inv.name(), flag.ContinueOnError)
+	inv.flags.SetOutput(io.Discard)
+	inv.run = inv.command().Setup(inv.flags)
+	addGlobalFlags(inv.flags)
+	return inv
+}
+
+// resolve finds the command to run and parses its flags.
+func resolve(args []string) (invocation, error) {
+	path, rest := findPath(args)
+	inv := setup(path)
+
+	positional, err := parseFlags(inv.flags, rest)
+	if err != nil {
+		return inv, err
+	}
+
+	if !slices.Contains(outputFormats, globals.Output) {
+		return inv, usageError("invalid output format %q", globals.Output)
+	}
+	if globals.Profile != "" && !ocs.ValidProfile(globals.Profile) {
+		return inv, usageError("invalid profile %q, expected only letters, digits, - and _", globals.Profile)
+	}
+
+	inv.positional = positional
+	return inv, nil
+}
+
+// runsPendingChanges reports whether queued and due scheduled status changes
+// are applied before the command runs. Only commands that change or fetch
+// your own status from the server apply them, so they are not overwritten
+// later. Other commands must not wait for the server, e.g. bars and prompts
+// that render from the cache.
+func runsPendingChanges(inv invocation) bool {
+	switch inv.path[0].Name {
+	case "update", "clear", "set", "exec", "focus":
+		return true
+	case "bar", "history":
+		// Cycling, clearing and applying change the status.
+		return len(inv.path) > 1 && inv.path[1].Name != "list"
+	case "get":
+		maxAge := inv.flags.Lookup("max-age")
+		return len(inv.positional) == 0 && (maxAge == nil || maxAge.Value.String() == "0s")
+	}
+	return false
+}
+
+// Main runs nsc with the given arguments and returns its exit code.
+func Main(args []string) int {
+	if len(args) > 0 && args[0] == completeCommand {
+		printCompletions(os.Stdout, complete(args[1:]))
+		return 0
+	}
+
+	inv, err := resolve(args)
+	if errors.Is(err, flag.ErrHelp) {
+		if len(inv.path) == 1 && inv.command() == commands[0] && !slices.Contains(args, "update") {
+			printOverview(os.Stdout)
+		} else {
+			printHelp(os.Stdout, inv)
+		}
+		return 0
+	} else if err != nil {
+		return printUsageError(inv, err)
+	}
+
+	if globals.Version {
+		fmt.Println("nsc", version())
+		return 0
+	}
+
+	applyGlobals()
+
+	// The daemon applies scheduled status changes on time. Without it they
+	// are applied by the next invocation, after the changes queued while
+	// the server was not reachable.
+	if runsPendingChanges(inv) {
+		if err := FlushQueue(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if err := RunDueSchedules(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	err = inv.run(inv.positional)
+
+	var exitErr ExitError
+	var usageErr UsageError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	} else if errors.As(err, &usageErr) {
+		return printUsageError(inv, err)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func applyGlobals() {
+	if globals.Profile != "" {
+		os.Setenv(ocs.ProfileEnv, globals.Profile)
+	}
+	if globals.NoColor {
+		// Respected by lipgloss.
+		os.Setenv("NO_COLOR", "1")
+	}
+	ocs.Debug = globals.Debug
+}
+
+func printUsageError(inv invocation, err error) int {
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	if inv.flags != nil {
+		fmt.Fprintf(os.Stderr, "Usage: %s\n", usageLine(inv))
+		fmt.Fprintf(os.Stderr, "Run \"nsc help %s\" for more information\n", strings.TrimPrefix(inv.name(), "nsc "))
+	}
+	return 2
+}
+
+func usageLine(inv invocation) string {
+	cmd := inv.command()
+	line := inv.name()
+	if len(cmd.Subcommands) > 0 {
+		line += " [command]"
+	}
+	line += " [flags]"
+	if cmd.Args != "" {
+		line += " " + cmd.Args
+	}
+	return line
+}
+
+func printHelp(w io.Writer, inv invocation) {
+	cmd := inv.command()
+	fmt.Fprintf(w, "%s\n\nUsage:\n  %s\n", cmd.Summary, usageLine(inv))
+
+	if len(cmd.Subcommands) > 0 {
+		fmt.Fprintln(w, "\nCommands:")
+		printCommands(w, cmd.Subcommands)
+	}
+
+	// The global flags are listed in the overview only.
+	globalFlags := flag.NewFlagSet("nsc", flag.ContinueOnError)
+	addGlobalFlags(globalFlags)
+	flags := flag.NewFlagSet(inv.name(), flag.ContinueOnError)
+	inv.flags.VisitAll(func(f *flag.Flag) {
+		if globalFlags.Lookup(f.Name) == nil {
+			flags.Var(f.Value, f.Name, f.Usage)
+			flags.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+
+	if hasFlags(flags) {
+		fmt.Fprintln(w, "\nFlags:")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+	fmt.Fprintln(w, "\nRun \"nsc help\" for a list of global flags.")
+}
+
+func hasFlags(flags *flag.FlagSet) bool {
+	found := false
+	flags.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+func printOverview(w io.Writer) {
+	fmt.Fprintln(w, "Set and watch your Nextcloud status from the command line")
+	fmt.Fprintln(w, "\nUsage:\n  nsc [command] [flags] [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	printCommands(w, commands)
+
+	fmt.Fprintln(w, "\nGlobal flags:")
+	flags := flag.NewFlagSet("nsc", flag.ContinueOnError)
+	addGlobalFlags(flags)
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+
+	fmt.Fprintln(w, "\nRun \"nsc help <command>\" for more information about a command.")
+}
+
+func printCommands(w io.Writer, cmds []*Command) {
+	width := 0
+	for _, cmd := range cmds {
+		width = max(width, len(cmd.Name))
+	}
+	for _, cmd := range cmds {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.Name, cmd.Summary)
+	}
+}
+
+func version() string {
+	if Version != "" {
+		return Version
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	v := info.Main.Version
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				modified = ", modified"
+			}
+		}
+	}
+	if revision != "" {
+		v += fmt.Sprintf(" (%.12s%s)", revision, modified)
+	}
+	return v + " " + info.GoVersion
+}
+
+func setupHelp(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) == 0 {
+			printOverview(os.Stdout)
+			return nil
+		}
+
+		inv, err := resolve(append(args, "--help"))
+		if !errors.Is(err, flag.ErrHelp) || inv.command() == commands[0] && args[0] != commands[0].Name {
+			return usageError("unknown command %q", strings.Join(args, " "))
+		}
+
+		printHelp(os.Stdout, inv)
+		return nil
+	}
+}
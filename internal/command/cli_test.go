@@ -0,0 +1,119 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("NSC_PROFILE", "")
+
+	tests := []struct {
+		args       []string
+		name       string
+		positional []string
+		globals    Globals
+	}{
+		{nil, "nsc update", nil, Globals{Output: outputText}},
+		{[]string{"--status", "dnd", "--submit"}, "nsc update", nil, Globals{Output: outputText}},
+		{[]string{"--profile", "work", "get", "--next-event"}, "nsc get", nil, Globals{Profile: "work", Output: outputText}},
+		{[]string{"--debug", "--output=json", "get"}, "nsc get", nil, Globals{Debug: true, Output: outputJson}},
+		{[]string{"get", "--no-color"}, "nsc get", nil, Globals{Output: outputText, NoColor: true}},
+		{[]string{"schedule", "cancel", "abc"}, "nsc schedule cancel", []string{"abc"}, Globals{Output: outputText}},
+		{[]string{"schedule", "--at", "12:00", "--message", "list"}, "nsc schedule", nil, Globals{Output: outputText}},
+		{[]string{"--profile", "work", "ooo", "--debug", "set", "--to", "friday"}, "nsc ooo set", nil, Globals{Profile: "work", Debug: true, Output: outputText}},
+		{[]string{"watch", "--interval", "1m", "alice", "--bell"}, "nsc watch", []string{"alice"}, Globals{Output: outputText}},
+		{[]string{"exec", "--message", "Build", "--", "make", "-j4"}, "nsc exec", []string{"make", "-j4"}, Globals{Output: outputText}},
+		{[]string{"help", "rule", "skip"}, "nsc help", []string{"rule", "skip"}, Globals{Output: outputText}},
+	}
+	for _, test := range tests {
+		inv, err := resolve(test.args)
+		require.NoError(t, err, test.args)
+		assert.Equal(t, test.name, inv.name(), test.args)
+		assert.Equal(t, test.positional, inv.positional, test.args)
+		assert.Equal(t, test.globals, globals, test.args)
+	}
+}
+
+func TestRunsPendingChanges(t *testing.T) {
+	t.Setenv("NSC_PROFILE", "")
+
+	for args, expected := range map[string]bool{
+		"":                  true,
+		"get":               true,
+		"get --max-age 1m":  false,
+		"bar":               false,
+		"bar cycle":         true,
+		"queue flush":       false,
+		"daemon --observe":  false,
+		"schedule list":     false,
+		"set focus --queue": true,
+		"get bob":           false,
+		"history":           false,
+		"history apply 1":   true,
+		"preset list":       false,
+		"watch bob":         false,
+		"dashboard":         false,
+		"exec -- true":      true,
+		"clear":             true,
+	} {
+		inv, err := resolve(strings.Fields(args))
+		require.NoError(t, err, args)
+		assert.Equal(t, expected, runsPendingChanges(inv), args)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	_, err := resolve([]string{"get", "--bogus"})
+	assert.EqualError(t, err, "flag provided but not defined: -bogus")
+
+	_, err = resolve([]string{"--output", "xml", "get"})
+	assert.Equal(t, UsageError{Message: `invalid output format "xml"`}, err)
+
+	_, err = resolve([]string{"--profile", "../work", "get"})
+	assert.IsType(t, UsageError{}, err)
+
+	inv, err := resolve([]string{"--profile", "work", "rule", "skip", "-h"})
+	assert.ErrorIs(t, err, flag.ErrHelp)
+	assert.Equal(t, "nsc rule skip [flags] <name>", usageLine(inv))
+
+	// Unknown commands are passed to the update command, which rejects them.
+	inv, err = resolve([]string{"frobnicate"})
+	require.NoError(t, err)
+	assert.Equal(t, UsageError{Message: `unknown command "frobnicate"`}, inv.run(inv.positional))
+}
+
+func TestPrintHelp(t *testing.T) {
+	inv, err := resolve([]string{"schedule", "--help"})
+	assert.ErrorIs(t, err, flag.ErrHelp)
+
+	var out bytes.Buffer
+	printHelp(&out, inv)
+	assert.Contains(t, out.String(), "Usage:\n  nsc schedule [command] [flags]\n")
+	assert.Contains(t, out.String(), "  cancel  Cancel a pending status change\n")
+	assert.Contains(t, out.String(), "-at string")
+	assert.NotContains(t, out.String(), "-profile")
+}
+
+func TestInvalidDurations(t *testing.T) {
+	for _, args := range []string{
+		"heartbeat --interval 0",
+		"heartbeat --away-after -1m",
+		"daemon --poll-interval 0",
+		"daemon --heartbeat-interval 0s",
+		"watch --interval 0 bob",
+		"exec --status busy -- true",
+		"exec --fail-status idle -- true",
+		"daemon install --timer 500ms",
+		"dashboard --interval 0",
+	} {
+		inv, err := resolve(strings.Fields(args))
+		require.NoError(t, err, args)
+		assert.IsType(t, UsageError{}, inv.run(inv.positional), args)
+	}
+}
@@ -0,0 +1,297 @@
+package command
+
+import "flag"
+
+// commands are the subcommands of nsc. The first one runs if no other
+// command is given.
+var commands = []*Command{
+	{
+		Name:    "update",
+		Summary: "Update your status in a form or directly with flags",
+		Setup:   setupUpdate,
+		Values: map[string]completer{
+			"status":     completeStatuses,
+			"emoji":      completeEmojis,
+			"timeout":    completeTimeouts,
+			"message-id": completePredefinedStatuses,
+		},
+	},
+	{
+		Name:    "auth",
+		Summary: "Log in to your Nextcloud server",
+		Setup:   withoutArgs(runAuth),
+	},
+	{
+		Name:    "bar",
+		Summary: "Print your status for a status bar or prompt",
+		Setup:   setupBar,
+		Values: map[string]completer{
+			"format": options(barFormats...),
+		},
+		Subcommands: []*Command{
+			{
+				Name:    "cycle",
+				Summary: "Switch to the next status",
+				Setup:   setupBarCycle,
+			},
+			{
+				Name:    "clear",
+				Summary: "Clear your status message without output",
+				Setup:   setupBarClear,
+			},
+		},
+	},
+	{
+		Name:    "calendar",
+		Summary: "Print upcoming meetings from your calendars",
+		Setup:   setupCalendar,
+	},
+	{
+		Name:    "clear",
+		Summary: "Clear your status message",
+		Setup:   setupClear,
+	},
+	{
+		Name:    "daemon",
+		Summary: "Run a long-running agent keeping your status up to date",
+		Setup:   setupDaemon,
+		Subcommands: []*Command{
+			{
+				Name:    "install",
+				Summary: "Install a systemd user service running the daemon",
+				Setup:   setupDaemonInstall,
+			},
+		},
+	},
+	{
+		Name:      "completion",
+		Args:      "bash|zsh|fish",
+		Summary:   "Print a shell completion script",
+		Setup:     setupCompletion,
+		ArgValues: argOptions(options(shells...)),
+	},
+	{
+		Name:    "dashboard",
+		Summary: "Show the statuses of your colleagues",
+		Setup:   setupDashboard,
+	},
+	{
+		Name:    "exec",
+		Args:    "-- <command> [args...]",
+		Summary: "Set a status while a command runs",
+		Setup:   setupExec,
+		Values: map[string]completer{
+			"status":       completeStatuses,
+			"emoji":        completeEmojis,
+			"timeout":      completeTimeouts,
+			"fail-status":  completeStatuses,
+			"fail-emoji":   completeEmojis,
+			"fail-timeout": completeTimeouts,
+		},
+	},
+	{
+		Name:    "focus",
+		Args:    "[duration]",
+		Summary: "Start a focus session with breaks",
+		Setup:   setupFocus,
+	},
+	{
+		Name:      "get",
+		Args:      "[user]",
+		Summary:   "Print your current status or the one of a colleague",
+		Setup:     setupGet,
+		ArgValues: argOptions(completeUsers),
+	},
+	{
+		Name:    "heartbeat",
+		Summary: "Report your activity to the server",
+		Setup:   setupHeartbeat,
+	},
+	{
+		Name:    "history",
+		Summary: "Print your recent status changes",
+		Setup:   setupHistoryList,
+		Subcommands: []*Command{
+			{
+				Name:    "list",
+				Summary: "Print your recent status changes",
+				Setup:   setupHistoryList,
+			},
+			{
+				Name:      "apply",
+				Args:      "<id>",
+				Summary:   "Set a status from your history again",
+				Setup:     setupHistoryApply,
+				ArgValues: argOptions(completeHistory),
+				Values: map[string]completer{
+					"timeout": completeTimeouts,
+				},
+			},
+		},
+	},
+	{
+		Name:    "ooo",
+		Summary: "Print your out-of-office period",
+		Setup:   withoutArgs(runOooGet),
+		Subcommands: []*Command{
+			{
+				Name:    "get",
+				Summary: "Print your out-of-office period",
+				Setup:   withoutArgs(runOooGet),
+			},
+			{
+				Name:    "set",
+				Summary: "Plan an out-of-office period",
+				Setup:   setupOooSet,
+			},
+			{
+				Name:    "clear",
+				Summary: "Remove your out-of-office period",
+				Setup:   withoutArgs(runOooClear),
+			},
+		},
+	},
+	{
+		Name:    "preset",
+		Summary: "Print your saved status presets",
+		Setup:   setupPresetList,
+		Subcommands: []*Command{
+			{
+				Name:    "list",
+				Summary: "Print your saved status presets",
+				Setup:   setupPresetList,
+			},
+			{
+				Name:    "add",
+				Args:    "<name>",
+				Summary: "Save a status preset",
+				Setup:   setupPresetAdd,
+				Values: map[string]completer{
+					"status":  completeStatuses,
+					"emoji":   completeEmojis,
+					"timeout": completeTimeouts,
+				},
+			},
+			{
+				Name:      "remove",
+				Args:      "<name>",
+				Summary:   "Remove a status preset",
+				Setup:     setupPresetRemove,
+				ArgValues: argOptions(completePresets),
+			},
+		},
+	},
+	{
+		Name:    "queue",
+		Summary: "Print status changes queued while the server was not reachable",
+		Setup:   setupQueueList,
+		Subcommands: []*Command{
+			{
+				Name:    "list",
+				Summary: "Print queued status changes",
+				Setup:   setupQueueList,
+			},
+			{
+				Name:    "flush",
+				Summary: "Apply queued status changes now",
+				Setup:   setupQueueFlush,
+			},
+			{
+				Name:      "drop",
+				Args:      "<id>...",
+				Summary:   "Drop queued status changes",
+				Setup:     setupQueueDrop,
+				ArgValues: completeQueued,
+			},
+		},
+	},
+	{
+		Name:    "report",
+		Summary: "Print how much time you spent in each status",
+		Setup:   setupReport,
+	},
+	{
+		Name:    "rule",
+		Summary: "Print the next occurrences of your rules",
+		Setup:   setupRuleList,
+		Subcommands: []*Command{
+			{
+				Name:    "list",
+				Summary: "Print the next occurrences of your rules",
+				Setup:   setupRuleList,
+			},
+			{
+				Name:      "skip",
+				Args:      "<name>",
+				Summary:   "Skip an occurrence of a rule",
+				Setup:     setupRuleSkip,
+				ArgValues: argOptions(completeRules),
+			},
+		},
+	},
+	{
+		Name:    "schedule",
+		Summary: "Schedule a status change",
+		Setup:   setupSchedule,
+		Values: map[string]completer{
+			"status":  completeStatuses,
+			"emoji":   completeEmojis,
+			"timeout": completeTimeouts,
+		},
+		Subcommands: []*Command{
+			{
+				Name:    "list",
+				Summary: "Print pending status changes",
+				Setup:   setupScheduleList,
+			},
+			{
+				Name:      "cancel",
+				Args:      "<id>",
+				Summary:   "Cancel a pending status change",
+				Setup:     setupScheduleCancel,
+				ArgValues: argOptions(completeSchedules),
+			},
+		},
+	},
+	{
+		Name:      "set",
+		Args:      "<preset>",
+		Summary:   "Set your status from a saved preset",
+		Setup:     setupSet,
+		ArgValues: argOptions(completePresets),
+	},
+	{
+		Name:    "watch",
+		Args:    "<user>",
+		Summary: "Print the status changes of a colleague",
+		Setup:   setupWatch,
+		Values: map[string]completer{
+			"until": options(statusOnline, statusAway, statusDnd, statusOffline),
+		},
+		ArgValues: argOptions(completeUsers),
+	},
+}
+
+func init() {
+	// Help looks up the other commands, so it is added here to avoid an
+	// initialization cycle.
+	commands = append(commands, &Command{
+		Name:      "help",
+		Args:      "[command]",
+		Summary:   "Print help about a command",
+		Setup:     setupHelp,
+		ArgValues: completeHelp,
+	})
+}
+
+// withoutArgs returns the setup of a command without flags and arguments.
+func withoutArgs(run func() error) func(flags *flag.FlagSet) func(args []string) error {
+	return func(flags *flag.FlagSet) func(args []string) error {
+		return func(args []string) error {
+			if len(args) > 0 {
+				return usageError("unexpected argument %q", args[0])
+			}
+			return run()
+		}
+	}
+}
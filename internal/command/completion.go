@@ -0,0 +1,499 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/emoji"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/queue"
+	"github.com/st3iny/nextcloud-status-command/internal/schedule"
+)
+
+// completeCommand is the hidden command called by the completion scripts. It
+// prints the completions of the last argument.
+const completeCommand = "__complete"
+
+const (
+	completionTimeout = 5 * time.Second
+	usersCacheTTL     = 24 * time.Hour
+	predefinedTTL     = 24 * time.Hour
+)
+
+type completion struct {
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// completer returns the completions of a flag value starting with the prefix.
+type completer func(prefix string) []completion
+
+// argCompleter returns the completions of the next positional argument.
+type argCompleter func(args []string, prefix string) []completion
+
+var shells = []string{"bash", "zsh", "fish"}
+
+func setupCompletion(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 1 {
+			return usageError("expected a shell [options: %s]", strings.Join(shells, ", "))
+		}
+
+		switch args[0] {
+		case "bash":
+			os.Stdout.WriteString(bashCompletion)
+		case "zsh":
+			os.Stdout.WriteString(zshCompletion)
+		case "fish":
+			os.Stdout.WriteString(fishCompletion)
+		default:
+			return usageError("unsupported shell %q", args[0])
+		}
+		return nil
+	}
+}
+
+// complete returns the completions of the last word.
+func complete(words []string) []completion {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	prefix := words[len(words)-1]
+	previous := words[:len(words)-1]
+
+	// Arguments after "--" are completed by the shell.
+	if slices.Contains(previous, "--") {
+		return nil
+	}
+
+	root, _ := findCommand(commands, previous)
+	path, rest := findPath(previous)
+	inv := setup(path)
+	cmd := inv.command()
+
+	// Parse the flags given so far for the global flags, e.g. --profile.
+	// Errors are expected as the command line is incomplete.
+	positional, _ := parseFlags(inv.flags, rest)
+	applyGlobals()
+
+	values := func(name, prefix string) []completion {
+		if complete, ok := cmd.Values[name]; ok {
+			return complete(prefix)
+		} else if complete, ok := globalValues[name]; ok {
+			return complete(prefix)
+		}
+		return nil
+	}
+
+	if len(previous) > 0 {
+		if name, ok := valueFlag(inv.flags, previous[len(previous)-1]); ok {
+			return values(name, prefix)
+		}
+	}
+
+	if strings.HasPrefix(prefix, "-") {
+		if name, value, ok := strings.Cut(prefix, "="); ok {
+			var completions []completion
+			for _, c := range values(strings.TrimLeft(name, "-"), value) {
+				c.Value = name + "=" + c.Value
+				completions = append(completions, c)
+			}
+			return completions
+		}
+
+		var completions []completion
+		inv.flags.VisitAll(func(f *flag.Flag) {
+			completions = append(completions, completion{Value: "--" + f.Name, Description: f.Usage})
+		})
+		return matching(prefix, completions)
+	}
+
+	if root == nil {
+		return matching(prefix, commandCompletions(commands))
+	}
+
+	var completions []completion
+	if len(cmd.Subcommands) > 0 && len(positional) == 0 {
+		completions = matching(prefix, commandCompletions(cmd.Subcommands))
+	}
+	if cmd.ArgValues != nil {
+		completions = append(completions, cmd.ArgValues(positional, prefix)...)
+	}
+	return completions
+}
+
+// valueFlag reports whether the argument is a flag expecting a value in the
+// next argument and returns its name.
+func valueFlag(flags *flag.FlagSet, arg string) (string, bool) {
+	if !strings.HasPrefix(arg, "-") || arg == "--" || strings.Contains(arg, "=") {
+		return "", false
+	}
+
+	name := strings.TrimLeft(arg, "-")
+	f := flags.Lookup(name)
+	if f == nil {
+		return "", false
+	}
+
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return name, !ok || !boolFlag.IsBoolFlag()
+}
+
+func printCompletions(w io.Writer, completions []completion) {
+	for _, c := range completions {
+		if c.Description == "" {
+			fmt.Fprintln(w, c.Value)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", c.Value, strings.ReplaceAll(c.Description, "\n", " "))
+		}
+	}
+}
+
+func matching(prefix string, completions []completion) []completion {
+	var matches []completion
+	for _, c := range completions {
+		if strings.HasPrefix(c.Value, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+func commandCompletions(cmds []*Command) []completion {
+	var completions []completion
+	for _, cmd := range cmds {
+		completions = append(completions, completion{Value: cmd.Name, Description: cmd.Summary})
+	}
+	return completions
+}
+
+// options completes a fixed list of values.
+func options(values ...string) completer {
+	return func(prefix string) []completion {
+		var completions []completion
+		for _, value := range values {
+			completions = append(completions, completion{Value: value})
+		}
+		return matching(prefix, completions)
+	}
+}
+
+// argOptions completes the first positional argument with the completer.
+func argOptions(complete completer) argCompleter {
+	return func(args []string, prefix string) []completion {
+		if len(args) > 0 {
+			return nil
+		}
+		return complete(prefix)
+	}
+}
+
+var (
+	completeStatuses = options(statusOnline, statusAway, statusDnd, statusInvisible)
+	completeTimeouts = options(
+		calendar.PresetNever,
+		calendar.Preset30Minutes,
+		calendar.Preset1Hour,
+		calendar.Preset4Hours,
+		calendar.PresetEndOfWorkday,
+		calendar.PresetNextWorkday,
+		calendar.PresetToday,
+		calendar.PresetThisWeek,
+	)
+)
+
+var globalValues = map[string]completer{
+	"output":  options(outputFormats...),
+	"profile": completeProfiles,
+}
+
+// completeEmojis completes shortcodes of emojis. An empty prefix is not
+// completed as there are too many emojis.
+func completeEmojis(prefix string) []completion {
+	if prefix == "" || prefix == ":" {
+		return nil
+	}
+
+	var completions []completion
+	for _, match := range emoji.Search(prefix) {
+		completions = append(completions, completion{Value: match.Shortcode, Description: match.Emoji.Emoji + " " + match.Description})
+	}
+	return completions
+}
+
+func completeProfiles(prefix string) []completion {
+	profiles, err := ocs.Profiles()
+	if err != nil {
+		return nil
+	}
+	return options(profiles...)(prefix)
+}
+
+func completeUsers(prefix string) []completion {
+	return matching(prefix, cachedCompletions("users", usersCacheTTL, func(auth ocs.Auth) ([]completion, error) {
+		statuses, err := ocs.GetStatuses(auth)
+		if err != nil {
+			return nil, err
+		}
+
+		var completions []completion
+		for _, status := range statuses {
+			completions = append(completions, completion{Value: status.User, Description: strings.TrimSpace(status.Icon + " " + status.Message)})
+		}
+		return completions, nil
+	}))
+}
+
+func completePredefinedStatuses(prefix string) []completion {
+	return matching(prefix, cachedCompletions("predefined", predefinedTTL, func(auth ocs.Auth) ([]completion, error) {
+		statuses, err := ocs.GetPredefinedStatuses(auth)
+		if err != nil {
+			return nil, err
+		}
+
+		var completions []completion
+		for _, status := range statuses {
+			completions = append(completions, completion{Value: status.Id, Description: status.Icon + " " + status.Message})
+		}
+		return completions, nil
+	}))
+}
+
+func completeRules(prefix string) []completion {
+	runner, err := newRulesRunner(nil)
+	if err != nil {
+		return nil
+	}
+
+	var completions []completion
+	for _, rule := range runner.Rules {
+		completions = append(completions, completion{Value: rule.Name, Description: describeStatus(runner.Calendar, rule.Status)})
+	}
+	return matching(prefix, completions)
+}
+
+func completeSchedules(prefix string) []completion {
+	cal, err := calendar.Load("")
+	if err != nil {
+		return nil
+	}
+
+	store, err := schedule.DefaultStore()
+	if err != nil {
+		return nil
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		return nil
+	}
+
+	var completions []completion
+	for _, entry := range entries {
+		completions = append(completions, completion{Value: entry.Id, Description: cal.Format(entry.At) + " " + describeStatus(cal, entry.Status)})
+	}
+	return matching(prefix, completions)
+}
+
+func completePresets(prefix string) []completion {
+	var completions []completion
+	for _, p := range loadPresets() {
+		completions = append(completions, completion{Value: p.Name, Description: describePreset(p)})
+	}
+	return matching(prefix, completions)
+}
+
+// completeHistory completes the ids of recent history entries, the newest
+// first.
+func completeHistory(prefix string) []completion {
+	cal, err := calendar.Load("")
+	if err != nil {
+		return nil
+	}
+
+	log, err := history.DefaultLog()
+	if err != nil {
+		return nil
+	}
+
+	entries, err := log.Read()
+	if err != nil {
+		return nil
+	}
+
+	var completions []completion
+	for i := len(entries) - 1; i >= 0 && len(completions) < 20; i-- {
+		completions = append(completions, completion{
+			Value:       strconv.Itoa(entries[i].Id),
+			Description: describeHistory(cal, entries[i]),
+		})
+	}
+	return matching(prefix, completions)
+}
+
+// completeQueued completes the ids of queued status changes that are not
+// given yet.
+func completeQueued(args []string, prefix string) []completion {
+	cal, err := calendar.Load("")
+	if err != nil {
+		return nil
+	}
+
+	store, err := queue.DefaultStore()
+	if err != nil {
+		return nil
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		return nil
+	}
+
+	var completions []completion
+	for _, entry := range entries {
+		if !slices.Contains(args, entry.Id) {
+			completions = append(completions, completion{Value: entry.Id, Description: describeQueued(cal, entry)})
+		}
+	}
+	return matching(prefix, completions)
+}
+
+// completeHelp completes the command path of help.
+func completeHelp(args []string, prefix string) []completion {
+	cmds := commands
+	for _, arg := range args {
+		i := slices.IndexFunc(cmds, func(cmd *Command) bool { return cmd.Name == arg })
+		if i < 0 {
+			return nil
+		}
+		cmds = cmds[i].Subcommands
+	}
+	return matching(prefix, commandCompletions(cmds))
+}
+
+// cachedCompletions returns completions fetched from the server. They are
+// cached per profile as fetching them takes too long while completing.
+func cachedCompletions(name string, ttl time.Duration, fetch func(auth ocs.Auth) ([]completion, error)) []completion {
+	dir := filepath.Join("nsc", "completion")
+	if profile := ocs.Profile(); profile != "" {
+		dir = filepath.Join(dir, "profiles", profile)
+	}
+
+	cachePath, err := xdg.CacheFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		return nil
+	}
+
+	if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < ttl {
+		var completions []completion
+		if data, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(data, &completions) == nil {
+			return completions
+		}
+	}
+
+	auth, err := ocs.LoadAuth()
+	if err != nil {
+		return nil
+	}
+
+	done := make(chan []completion, 1)
+	go func() {
+		completions, err := fetch(auth)
+		if err != nil {
+			completions = nil
+		}
+		done <- completions
+	}()
+
+	var completions []completion
+	select {
+	case completions = <-done:
+	case <-time.After(completionTimeout):
+		return nil
+	}
+
+	if completions != nil {
+		if data, err := json.Marshal(completions); err == nil {
+			os.WriteFile(cachePath, data, 0600)
+		}
+	}
+	return completions
+}
+
+const bashCompletion = `# bash completion for nsc
+
+_nsc() {
+	local line=${COMP_LINE:0:COMP_POINT}
+	local -a words
+	read -ra words <<< "$line"
+	if [[ -z $line || $line == *' ' ]]; then
+		words+=("")
+	fi
+
+	local IFS=$'\n'
+	COMPREPLY=($(nsc __complete "${words[@]:1}" 2>/dev/null | cut -f1))
+
+	# Bash splits words at colons and equal signs, so the part before them is
+	# removed from the completions.
+	local cur=${words[-1]}
+	local prefix=${cur%"${COMP_WORDS[COMP_CWORD]}"}
+	if [[ -n $prefix ]]; then
+		COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+	fi
+
+	# Values like preset names or times may contain spaces.
+	local i
+	for i in "${!COMPREPLY[@]}"; do
+		COMPREPLY[i]=$(printf %q "${COMPREPLY[i]}")
+	done
+}
+
+complete -o default -F _nsc nsc
+`
+
+const zshCompletion = `#compdef nsc
+
+_nsc() {
+	local -a lines values display
+	local line
+	lines=("${(@f)$(nsc __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	for line in $lines; do
+		[[ -z $line ]] && continue
+		values+=("${line%%$'\t'*}")
+		if [[ $line == *$'\t'* ]]; then
+			display+=("${line%%$'\t'*}  -- ${line#*$'\t'}")
+		else
+			display+=("$line")
+		fi
+	done
+
+	if (( ${#values} )); then
+		compadd -U -l -d display -a values
+	else
+		_files
+	fi
+}
+
+compdef _nsc nsc
+`
+
+const fishCompletion = `# fish completion for nsc
+
+function __nsc_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    nsc __complete $args 2>/dev/null
+end
+
+complete -c nsc -f -a '(__nsc_complete)'
+`
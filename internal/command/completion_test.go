@@ -0,0 +1,42 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplete(t *testing.T) {
+	t.Setenv("NSC_PROFILE", "")
+
+	values := func(words ...string) []string {
+		var values []string
+		for _, c := range complete(words) {
+			values = append(values, c.Value)
+		}
+		return values
+	}
+
+	assert.Equal(t, []string{"daemon", "dashboard"}, values("da"))
+	assert.Equal(t, []string{"schedule"}, values("--debug", "sch"))
+	assert.Equal(t, []string{"list", "cancel"}, values("schedule", ""))
+	assert.Equal(t, []string{"dnd"}, values("--status", "d"))
+	assert.Equal(t, []string{"away"}, values("exec", "--fail-status", "aw"))
+	assert.Equal(t, []string{"--output=json"}, values("get", "--output=j"))
+	assert.Equal(t, []string{"30 minutes"}, values("schedule", "--at", "12:00", "--timeout", "3"))
+	assert.Equal(t, []string{"--message", "--message-id"}, values("--mess"))
+	assert.Equal(t, []string{"offline"}, values("watch", "--until", "off"))
+	assert.Equal(t, []string{"skip"}, values("help", "rule", "s"))
+	assert.Equal(t, []string{"zsh"}, values("completion", "z"))
+	assert.Contains(t, values("--emoji", ":hot_bev"), ":hot_beverage:")
+	assert.Contains(t, values("--emoji", ":coff"), ":coffee:")
+
+	// Bool flags do not take a value.
+	assert.Equal(t, []string{"help"}, values("--submit", "hel"))
+
+	// The status of ooo set is a message.
+	assert.Empty(t, values("ooo", "set", "--status", ""))
+
+	// The arguments of the command are completed by the shell.
+	assert.Empty(t, values("exec", "--", "ma"))
+}
@@ -0,0 +1,213 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/cache"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/daemon"
+	"github.com/st3iny/nextcloud-status-command/internal/heartbeat"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/meeting"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/queue"
+)
+
+func setupDaemon(flags *flag.FlagSet) func(args []string) error {
+	pollInterval := flags.Duration("poll-interval", time.Minute, "interval in which your status is refreshed")
+	sendHeartbeats := flags.Bool("heartbeat", true, "report your activity to the server")
+	heartbeatInterval := flags.Duration("heartbeat-interval", 2*time.Minute, "interval between heartbeats")
+	awayAfter := flags.Duration("away-after", 5*time.Minute, "idle time after which you are reported as away")
+	idleCommand := flags.String("idle-command", "", "command printing your idle time in milliseconds (e.g. xprintidle), defaults to the idle time of your terminals")
+	calendarInterval := flags.Duration("calendar-interval", 5*time.Minute, "interval in which calendars are fetched again")
+	observe := flags.Bool("observe", false, "also record status changes made elsewhere, e.g. in the web interface, in the history")
+	once := flags.Bool("once", false, "run the periodic jobs once and exit unless the daemon is already running")
+	return func(args []string) error {
+		durations := []struct {
+			name  string
+			value time.Duration
+		}{
+			{"poll-interval", *pollInterval},
+			{"heartbeat-interval", *heartbeatInterval},
+			{"away-after", *awayAfter},
+			{"calendar-interval", *calendarInterval},
+		}
+		for _, duration := range durations {
+			if err := checkPositive(duration.name, duration.value); err != nil {
+				return err
+			}
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		socketPath, err := daemon.SocketPath()
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("Failed to load config: %s", err)
+		}
+
+		d := &daemon.Daemon{
+			Auth:         auth,
+			SocketPath:   socketPath,
+			PollInterval: *pollInterval,
+		}
+		if store, err := cache.DefaultStore(); err == nil {
+			d.StatusCache = &store
+		}
+		if path, err := daemon.RestorePath(); err == nil {
+			d.RestorePath = path
+		}
+		if log, err := history.DefaultLog(); err == nil {
+			d.History = &log
+			d.RecordObserved = *observe
+		}
+		if cfg.OffHoursStatus != "" {
+			cal, err := calendar.FromConfig(cfg, "")
+			if err != nil {
+				return err
+			}
+
+			d.Calendar = &cal
+			d.OffHoursStatus = cfg.OffHoursStatus
+		}
+
+		setStatus := func(status ocs.UserStatus) error {
+			return d.Set(daemon.SetParams{Status: status})
+		}
+
+		if store, err := queue.DefaultStore(); err == nil {
+			d.Queue = &queue.Runner{Store: store, Apply: setStatus, Clear: d.Clear}
+		}
+
+		d.Schedule, err = newScheduleRunner(cfg, setStatus)
+		if err != nil {
+			return err
+		}
+
+		d.Rules, err = newRulesRunner(setStatus)
+		if err != nil {
+			return err
+		}
+
+		if len(cfg.Calendars) > 0 {
+			sources, err := loadCalendars(cfg, &auth)
+			if err != nil {
+				return err
+			}
+
+			d.Meetings = &meeting.Watcher{
+				Sources:         sources,
+				RefreshInterval: *calendarInterval,
+				Apply: func(status ocs.UserStatus) error {
+					return d.Set(daemon.SetParams{Status: status, Restore: true})
+				},
+			}
+		}
+
+		if *sendHeartbeats {
+			d.Heartbeat = &heartbeat.Heartbeat{
+				Auth:      auth,
+				Source:    newActivitySource(*idleCommand),
+				Interval:  *heartbeatInterval,
+				AwayAfter: *awayAfter,
+			}
+		}
+
+		if *once {
+			if client, err := daemon.DialPath(socketPath); err == nil {
+				client.Close()
+				return nil
+			}
+
+			d.Tick(time.Now())
+			if d.Heartbeat != nil {
+				activity, status, err := d.Heartbeat.Beat()
+				printHeartbeat(activity, status, err)
+			}
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return d.Run(ctx)
+	}
+}
+
+func setupDaemonInstall(flags *flag.FlagSet) func(args []string) error {
+	timer := flags.Duration("timer", 0, "also install a timer running the periodic jobs in this interval (e.g. 5m)")
+	printUnits := flags.Bool("print", false, "print the units instead of installing them")
+	return func(args []string) error {
+		if *timer != 0 && *timer < time.Second {
+			return usageError("invalid value %s for --timer, expected at least 1s", *timer)
+		}
+
+		// The global --profile flag selects the profile to run the daemon for.
+		profile := ocs.Profile()
+
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("Failed to find the path of nsc: %s", err)
+		}
+
+		executable, err = filepath.EvalSymlinks(executable)
+		if err != nil {
+			return fmt.Errorf("Failed to find the path of nsc: %s", err)
+		}
+
+		units, err := daemon.Units(daemon.UnitOptions{
+			Executable:    executable,
+			Profile:       profile,
+			TimerInterval: *timer,
+		})
+		if err != nil {
+			return err
+		}
+
+		if *printUnits {
+			for _, unit := range units {
+				fmt.Printf("# %s\n%s\n", unit.Name, unit.Content)
+			}
+			return nil
+		}
+
+		for _, unit := range units {
+			unitPath := filepath.Join(xdg.ConfigHome, "systemd", "user", unit.Name)
+			if err := os.MkdirAll(filepath.Dir(unitPath), 0755); err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(unitPath, []byte(unit.Content), 0644); err != nil {
+				return err
+			}
+
+			fmt.Println("Installed", unitPath)
+		}
+
+		name := daemon.UnitName(profile)
+		fmt.Println()
+		fmt.Println("Run the following commands to start the daemon:")
+		fmt.Println("  systemctl --user daemon-reload")
+		fmt.Printf("  systemctl --user enable --now %s.service\n", name)
+		if *timer > 0 {
+			fmt.Printf("  systemctl --user enable --now %s-once.timer\n", name)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,471 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const dashboardMaxBackoff = 5 * time.Minute
+
+var (
+	statusColors = map[string]lipgloss.Color{
+		statusOnline:    lipgloss.Color("#49b382"),
+		statusAway:      lipgloss.Color("#f4a331"),
+		statusDnd:       lipgloss.Color("#ed484c"),
+		statusInvisible: lipgloss.Color("#b4b4b4"),
+	}
+
+	dashboardTitleStyle   = lipgloss.NewStyle().Bold(true)
+	dashboardGroupStyle   = lipgloss.NewStyle().Bold(true).MarginTop(1)
+	dashboardChangedStyle = lipgloss.NewStyle().Bold(true).Reverse(true)
+	dashboardMutedStyle   = lipgloss.NewStyle().Faint(true)
+	dashboardErrorStyle   = lipgloss.NewStyle().Foreground(statusColors[statusDnd])
+)
+
+func setupDashboard(flags *flag.FlagSet) func(args []string) error {
+	interval := flags.Duration("interval", 30*time.Second, "interval between polls of your colleagues' statuses")
+	tz := flags.String("tz", "", "time zone to resolve timeouts in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+		if err := checkPositive("interval", *interval); err != nil {
+			return err
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		p := tea.NewProgram(newDashboardModel(auth, cal, *interval), tea.WithAltScreen())
+		_, err = p.Run()
+		if err != nil {
+			return fmt.Errorf("Failed to render dashboard: %s", err)
+		}
+
+		return nil
+	}
+}
+
+type statusesMsg struct {
+	statuses []ocs.UserStatus
+	err      error
+}
+
+type pollMsg struct {
+	generation int
+}
+
+type ownStatusMsg struct {
+	status *ocs.UserStatus
+	err    error
+}
+
+type updatedMsg struct {
+	err error
+}
+
+type dashboardModel struct {
+	auth     ocs.Auth
+	calendar calendar.Calendar
+	interval time.Duration
+
+	statuses   []ocs.UserStatus
+	changed    map[string]bool
+	polled     bool
+	lastPoll   time.Time
+	nextPoll   time.Time
+	pollErr    error
+	failures   int
+	generation int
+
+	filter    textinput.Model
+	filtering bool
+
+	edit   *updateModel
+	notice string
+
+	width  int
+	height int
+	offset int
+}
+
+func newDashboardModel(auth ocs.Auth, cal calendar.Calendar, interval time.Duration) dashboardModel {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "filter by user, status or message"
+
+	return dashboardModel{
+		auth:     auth,
+		calendar: cal,
+		interval: interval,
+		changed:  map[string]bool{},
+		filter:   filter,
+	}
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return m.fetchStatuses
+}
+
+func (m dashboardModel) fetchStatuses() tea.Msg {
+	statuses, err := ocs.GetStatuses(m.auth)
+	return statusesMsg{statuses: statuses, err: err}
+}
+
+func (m dashboardModel) fetchOwnStatus() tea.Msg {
+	status, err := getStatus(m.auth)
+	return ownStatusMsg{status: status, err: err}
+}
+
+// backoff doubles the poll interval for every consecutive failure.
+func (m dashboardModel) backoff() time.Duration {
+	delay := m.interval
+	for i := 0; i < m.failures && delay < dashboardMaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return max(m.interval, min(delay, dashboardMaxBackoff))
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	if m, ok := model.(dashboardModel); ok {
+		// Polls and filters shrink the list, so keep the last line in view.
+		m.offset = min(m.offset, m.maxOffset())
+		return m, cmd
+	}
+
+	return model, cmd
+}
+
+func (m dashboardModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case statusesMsg:
+		return m.receiveStatuses(msg)
+	case pollMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+
+		return m, m.fetchStatuses
+	case ownStatusMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Failed to fetch your current status: %s", msg.err)
+			return m, nil
+		}
+
+		return m.openEditor(msg.status)
+	case updatedMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Failed to update your status: %s", msg.err)
+		} else {
+			m.notice = "Your status was updated"
+		}
+
+		m.generation++
+		return m, m.fetchStatuses
+	}
+
+	if m.edit != nil {
+		return m.updateEditor(msg)
+	}
+
+	if m.filtering {
+		return m.updateFilter(msg)
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "/":
+			m.filtering = true
+			m.offset = 0
+			return m, m.filter.Focus()
+		case "e":
+			m.notice = "Fetching your current status ..."
+			return m, m.fetchOwnStatus
+		case "r":
+			m.generation++
+			return m, m.fetchStatuses
+		case "up", "k":
+			m.offset = max(0, m.offset-1)
+		case "down", "j":
+			m.offset++
+		}
+	}
+
+	return m, nil
+}
+
+func (m dashboardModel) receiveStatuses(msg statusesMsg) (tea.Model, tea.Cmd) {
+	now := time.Now()
+	if msg.err != nil {
+		m.failures++
+		m.pollErr = msg.err
+	} else {
+		m.changed = map[string]bool{}
+		if m.polled {
+			previous := map[string]ocs.UserStatus{}
+			for _, status := range m.statuses {
+				previous[status.User] = status
+			}
+			for _, status := range msg.statuses {
+				if old, ok := previous[status.User]; !ok || old != status {
+					m.changed[status.User] = true
+				}
+			}
+		}
+
+		m.statuses = msg.statuses
+		m.polled = true
+		m.lastPoll = now
+		m.failures = 0
+		m.pollErr = nil
+	}
+
+	m.generation++
+	generation := m.generation
+	delay := m.backoff()
+	m.nextPoll = now.Add(delay)
+	return m, tea.Tick(delay, func(time.Time) tea.Msg {
+		return pollMsg{generation: generation}
+	})
+}
+
+func (m dashboardModel) openEditor(status *ocs.UserStatus) (tea.Model, tea.Cmd) {
+	if status == nil {
+		status = &ocs.UserStatus{Status: statusOnline}
+	}
+
+	statusValue := status.Status
+	emojiValue := status.Icon
+	messageValue := status.Message
+	timeoutValue := status.ClearAt
+	edit := newUpdateModel(m.calendar, &statusValue, &emojiValue, &messageValue, &timeoutValue, loadQuickPicks())
+	m.edit = &edit
+	m.notice = ""
+	return m, edit.Init()
+}
+
+func (m dashboardModel) updateEditor(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.edit = nil
+			return m, nil
+		}
+	}
+
+	form, cmd := m.edit.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.edit.form = f
+	}
+
+	if m.edit.form.State != huh.StateCompleted {
+		return m, cmd
+	}
+
+	auth := m.auth
+	status, err := m.edit.status()
+	m.edit = nil
+	if err != nil {
+		m.notice = fmt.Sprintf("Failed to update your status: %s", err)
+		return m, nil
+	}
+
+	m.notice = "Updating your status ..."
+	return m, func() tea.Msg {
+		return updatedMsg{err: updateStatus(auth, status.Status, status.Message, status.Icon, status.ClearAt, false)}
+	}
+}
+
+func (m dashboardModel) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.filtering = false
+			m.filter.Reset()
+			m.filter.Blur()
+			return m, nil
+		case "enter":
+			m.filtering = false
+			m.filter.Blur()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	return m, cmd
+}
+
+func (m dashboardModel) View() string {
+	if m.edit != nil {
+		return m.edit.View() + "\n" + dashboardMutedStyle.Render("esc back to dashboard")
+	}
+
+	header := []string{dashboardTitleStyle.Render("Nextcloud status dashboard")}
+	if m.filtering || m.filter.Value() != "" {
+		header = append(header, m.filter.View())
+	}
+
+	body := m.groupLines()
+	footer := m.footerLines()
+
+	if m.height > 0 {
+		visible := m.visibleLines()
+		offset := min(m.offset, max(0, len(body)-visible))
+		body = body[offset:min(len(body), offset+visible)]
+	}
+
+	lines := append(header, body...)
+	lines = append(lines, footer...)
+	return strings.Join(lines, "\n")
+}
+
+// visibleLines returns the number of status lines that fit between the header
+// and the footer.
+func (m dashboardModel) visibleLines() int {
+	header := 1
+	if m.filtering || m.filter.Value() != "" {
+		header++
+	}
+
+	return max(1, m.height-header-len(m.footerLines()))
+}
+
+// maxOffset returns the largest scroll offset that still shows the last line.
+func (m dashboardModel) maxOffset() int {
+	if m.height <= 0 {
+		return 0
+	}
+
+	return max(0, len(m.groupLines())-m.visibleLines())
+}
+
+func (m dashboardModel) groupLines() []string {
+	if !m.polled {
+		return []string{"", "Fetching statuses ..."}
+	}
+
+	query := strings.ToLower(m.filter.Value())
+	groups := map[string][]ocs.UserStatus{}
+	for _, status := range m.statuses {
+		haystack := strings.ToLower(strings.Join([]string{status.User, status.Status, status.Message}, " "))
+		if query != "" && !strings.Contains(haystack, query) {
+			continue
+		}
+
+		groups[status.Status] = append(groups[status.Status], status)
+	}
+
+	order := []string{statusOnline, statusAway, statusDnd}
+	var others []string
+	for status := range groups {
+		if !slices.Contains(order, status) {
+			others = append(others, status)
+		}
+	}
+	slices.Sort(others)
+	order = append(order, others...)
+
+	var lines []string
+	for _, status := range order {
+		members := groups[status]
+		if len(members) == 0 {
+			continue
+		}
+
+		slices.SortFunc(members, func(a, b ocs.UserStatus) int {
+			return strings.Compare(a.User, b.User)
+		})
+
+		color, ok := statusColors[status]
+		if !ok {
+			color = statusColors[statusInvisible]
+		}
+		indicator := lipgloss.NewStyle().Foreground(color).Render("●")
+		lines = append(lines, dashboardGroupStyle.Render(fmt.Sprintf("%s %s (%d)", indicator, status, len(members))))
+
+		for _, member := range members {
+			line := member.User
+			if member.Icon != "" {
+				line += " " + member.Icon
+			}
+			if member.Message != "" {
+				line += " " + member.Message
+			}
+			if member.ClearAt > 0 {
+				line += dashboardMutedStyle.Render(" until " + time.Unix(member.ClearAt, 0).Format("Mon 15:04"))
+			}
+
+			if m.changed[member.User] {
+				lines = append(lines, "  "+dashboardChangedStyle.Render(line))
+			} else {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "", "No matching statuses")
+	}
+
+	return lines
+}
+
+func (m dashboardModel) footerLines() []string {
+	var connection string
+	if m.pollErr != nil {
+		connection = dashboardErrorStyle.Render(fmt.Sprintf(
+			"● disconnected: %s (retrying at %s)",
+			m.pollErr,
+			m.nextPoll.Format("15:04:05"),
+		))
+	} else if m.polled {
+		connection = fmt.Sprintf(
+			"%s connected, updated at %s, next update at %s",
+			lipgloss.NewStyle().Foreground(statusColors[statusOnline]).Render("●"),
+			m.lastPoll.Format("15:04:05"),
+			m.nextPoll.Format("15:04:05"),
+		)
+	} else {
+		connection = dashboardMutedStyle.Render("● connecting ...")
+	}
+
+	lines := []string{""}
+	if m.notice != "" {
+		lines = append(lines, m.notice)
+	}
+	lines = append(lines,
+		connection,
+		dashboardMutedStyle.Render("/ filter • e edit your status • r refresh • ↑/↓ scroll • q quit"),
+	)
+	return lines
+}
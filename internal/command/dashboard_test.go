@@ -0,0 +1,84 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newDashboardModel(ocs.Auth{}, calendar.Default(), 30*time.Second)
+	assert.Equal(30*time.Second, m.backoff())
+
+	m.failures = 1
+	assert.Equal(time.Minute, m.backoff())
+
+	m.failures = 3
+	assert.Equal(4*time.Minute, m.backoff())
+
+	m.failures = 10
+	assert.Equal(dashboardMaxBackoff, m.backoff())
+}
+
+func TestDashboardHighlightsChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newDashboardModel(ocs.Auth{}, calendar.Default(), 30*time.Second)
+	model, _ := m.receiveStatuses(statusesMsg{statuses: []ocs.UserStatus{
+		{User: "alice", Status: statusOnline},
+		{User: "bob", Status: statusAway},
+	}})
+	m = model.(dashboardModel)
+	assert.Empty(m.changed)
+
+	model, _ = m.receiveStatuses(statusesMsg{err: errors.New("offline")})
+	m = model.(dashboardModel)
+	assert.Equal(1, m.failures)
+	assert.Len(m.statuses, 2)
+
+	model, _ = m.receiveStatuses(statusesMsg{statuses: []ocs.UserStatus{
+		{User: "alice", Status: statusOnline},
+		{User: "bob", Status: statusDnd, Message: "Focus"},
+		{User: "carol", Status: statusOnline},
+	}})
+	m = model.(dashboardModel)
+	assert.Equal(0, m.failures)
+	assert.Equal(map[string]bool{"bob": true, "carol": true}, m.changed)
+}
+
+func TestDashboardRejectsArguments(t *testing.T) {
+	inv, err := resolve([]string{"dashboard", "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, UsageError{Message: `unexpected argument "bob"`}, inv.run(inv.positional))
+}
+
+func TestDashboardClampsScrollOffset(t *testing.T) {
+	assert := assert.New(t)
+
+	var statuses []ocs.UserStatus
+	for _, user := range []string{"alice", "bob", "carol", "dave", "erin", "frank"} {
+		statuses = append(statuses, ocs.UserStatus{User: user, Status: statusOnline})
+	}
+
+	m := newDashboardModel(ocs.Auth{}, calendar.Default(), 30*time.Second)
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 8})
+	model, _ = model.Update(statusesMsg{statuses: statuses})
+	for range 10 {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	m = model.(dashboardModel)
+	assert.Equal(m.maxOffset(), m.offset)
+	assert.Positive(m.offset)
+
+	model, _ = m.Update(statusesMsg{statuses: statuses[:2]})
+	m = model.(dashboardModel)
+	assert.Equal(0, m.offset)
+}
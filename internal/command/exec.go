@@ -0,0 +1,184 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/emoji"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+// ExitError makes nsc exit with the given code without printing anything.
+type ExitError struct {
+	Code int
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func setupExec(flags *flag.FlagSet) func(args []string) error {
+	statusValue := flags.String("status", "", "your status while the command runs, defaults to your current status")
+	emojiValue := flags.String("emoji", "", "your status emoji or its shortcode while the command runs")
+	message := flags.String("message", "", "your status message while the command runs")
+	timeout := flags.String("timeout", calendar.PresetNever, "timeout after which to delete the status")
+	failStatus := flags.String("fail-status", "", "your status if the command fails, defaults to --status")
+	failEmoji := flags.String("fail-emoji", "", "your status emoji if the command fails")
+	failMessage := flags.String("fail-message", "", "your status message if the command fails")
+	failTimeout := flags.String("fail-timeout", calendar.PresetNever, "timeout after which to delete the failure status")
+	tz := flags.String("tz", "", "time zone to resolve the timeouts in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	return func(command []string) error {
+		if len(command) == 0 {
+			return usageError("missing command")
+		}
+		for _, status := range []string{*statusValue, *failStatus} {
+			if status == "" {
+				continue
+			}
+			if err := checkStatus(status); err != nil {
+				return err
+			}
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		clearAt, err := cal.ParseTimeout(*timeout, cal.Now())
+		if err != nil {
+			return err
+		}
+
+		previous, err := getStatus(auth)
+		if err != nil {
+			return fmt.Errorf("Failed to get your current status: %s", err)
+		}
+
+		set := ocs.UserStatus{
+			User:    auth.User,
+			Status:  *statusValue,
+			Icon:    emoji.Resolve(*emojiValue),
+			Message: *message,
+			ClearAt: clearAt,
+		}
+		if set.Status == "" {
+			set.Status = statusOnline
+			if previous != nil {
+				set.Status = previous.Status
+			}
+		}
+
+		if err := updateStatus(auth, set.Status, set.Message, set.Icon, set.ClearAt, false); err != nil {
+			return fmt.Errorf("Failed to update your status: %s", err)
+		}
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+		code, runErr := runChild(command, signals, term.IsTerminal(os.Stdin.Fd()))
+		signal.Stop(signals)
+
+		if code != 0 && (*failStatus != "" || *failEmoji != "" || *failMessage != "") {
+			fail := ocs.UserStatus{
+				Status:  valueOr(*failStatus, set.Status),
+				Icon:    emoji.Resolve(*failEmoji),
+				Message: *failMessage,
+			}
+			fail.ClearAt, err = cal.ParseTimeout(*failTimeout, cal.Now())
+			if err != nil {
+				return err
+			}
+
+			if replaced, err := replaceStatus(auth, set, fail); err != nil {
+				return fmt.Errorf("Failed to update your status: %s", err)
+			} else if !replaced {
+				fmt.Fprintln(os.Stderr, "Your status was changed while the command was running and is left as is")
+			}
+		} else if restored, err := restoreStatus(auth, set, previous); err != nil {
+			return fmt.Errorf("Failed to restore your status: %s", err)
+		} else if !restored {
+			fmt.Fprintln(os.Stderr, "Your status was changed while the command was running and is left as is")
+		}
+
+		if runErr != nil {
+			return runErr
+		}
+		if code != 0 {
+			return ExitError{Code: code}
+		}
+		return nil
+	}
+}
+
+// replaceStatus sets a new status unless the current status no longer
+// matches the one that was set. It reports whether the status was replaced.
+func replaceStatus(auth ocs.Auth, set, status ocs.UserStatus) (bool, error) {
+	current, err := getStatus(auth)
+	if err != nil {
+		return false, err
+	}
+
+	if current == nil || !ocs.SameStatus(*current, set, time.Now().Unix()) {
+		return false, nil
+	}
+
+	return true, updateStatus(auth, status.Status, status.Message, status.Icon, status.ClearAt, false)
+}
+
+// runChild runs the command with the standard streams of nsc and forwards the
+// received signals to it. If nsc runs in a terminal, SIGINT and SIGQUIT are
+// not forwarded as the terminal already sends them to the whole foreground
+// process group, including the command. It returns the exit code of the
+// command.
+func runChild(command []string, signals <-chan os.Signal, terminal bool) (int, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return 127, fmt.Errorf("Failed to run %s: %s", command[0], err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if !terminal || sig != os.Interrupt && sig != syscall.SIGQUIT {
+					cmd.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	close(done)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal()), nil
+		}
+		return exitErr.ExitCode(), nil
+	} else if err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunChild(t *testing.T) {
+	code, err := runChild([]string{"sh", "-c", "exit 3"}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, code)
+
+	code, err = runChild([]string{"true"}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+
+	_, err = runChild([]string{filepath.Join(t.TempDir(), "missing")}, nil, false)
+	assert.Error(t, err)
+}
+
+func TestRunChildForwardsSignals(t *testing.T) {
+	for terminal, expected := range map[bool]int{false: 5, true: 7} {
+		ready := filepath.Join(t.TempDir(), "ready")
+		signals := make(chan os.Signal, 1)
+		go func() {
+			assert.Eventually(t, func() bool {
+				_, err := os.Stat(ready)
+				return err == nil
+			}, 5*time.Second, 10*time.Millisecond)
+
+			// In a terminal, SIGINT already reaches the command.
+			signals <- os.Interrupt
+			signals <- syscall.SIGTERM
+		}()
+
+		code, err := runChild([]string{"sh", "-c", `trap "exit 5" INT; trap "exit 7" TERM; touch "$0"; while true; do sleep 0.01; done`, ready}, signals, terminal)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, terminal)
+	}
+}
@@ -0,0 +1,31 @@
+package command
+
+import (
+	"flag"
+	"slices"
+)
+
+// parseFlags parses flags that may appear before, after or in between
+// positional arguments. Everything after a "--" is treated as positional.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, rest = args[:i], args[i+1:]
+	}
+
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	return append(positional, rest...), nil
+}
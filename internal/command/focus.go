@@ -0,0 +1,410 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const (
+	focusIcon      = "🍅"
+	focusExtension = 5 * time.Minute
+)
+
+var (
+	focusTitleStyle = lipgloss.NewStyle().Bold(true)
+	focusClockStyle = lipgloss.NewStyle().Bold(true).Foreground(statusColors[statusDnd])
+	focusBreakStyle = lipgloss.NewStyle().Bold(true).Foreground(statusColors[statusOnline])
+	focusMutedStyle = lipgloss.NewStyle().Faint(true)
+)
+
+type focusPhase struct {
+	focus    bool
+	duration time.Duration
+}
+
+// focusPhases returns the given number of focus sessions with breaks in
+// between. Every longBreakEvery sessions, a long break is taken.
+func focusPhases(duration time.Duration, cycles int, shortBreak, longBreak time.Duration, longBreakEvery int) []focusPhase {
+	var phases []focusPhase
+	for i := 1; i <= cycles; i++ {
+		phases = append(phases, focusPhase{focus: true, duration: duration})
+		if i == cycles {
+			break
+		}
+
+		breakDuration := shortBreak
+		if longBreakEvery > 0 && i%longBreakEvery == 0 {
+			breakDuration = longBreak
+		}
+		if breakDuration > 0 {
+			phases = append(phases, focusPhase{duration: breakDuration})
+		}
+	}
+
+	return phases
+}
+
+func setupFocus(flags *flag.FlagSet) func(args []string) error {
+	// The defaults of the flags are read from the config. Errors are reported
+	// once the command runs, so help is printed in any case.
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		cfgErr = fmt.Errorf("Failed to load config: %s", cfgErr)
+	}
+
+	defaults := cfg.Focus
+	defaultDuration, durationErr := parseFocusDuration(defaults.Duration, 25*time.Minute)
+	defaultBreak, breakErr := parseFocusDuration(defaults.Break, 5*time.Minute)
+	defaultLongBreak, longBreakErr := parseFocusDuration(defaults.LongBreak, 15*time.Minute)
+
+	message := flags.String("message", valueOr(defaults.Message, "Focusing"), "status message during focus sessions")
+	cycles := flags.Int("cycles", max(1, defaults.Cycles), "number of focus sessions with breaks in between")
+	shortBreak := flags.Duration("break", defaultBreak, "length of the breaks between focus sessions")
+	longBreak := flags.Duration("long-break", defaultLongBreak, "length of the long breaks")
+	longBreakEvery := flags.Int("long-break-every", valueOr(defaults.LongBreakEvery, 4), "number of focus sessions after which a long break is taken")
+	return func(positional []string) error {
+		if err := errors.Join(cfgErr, durationErr, breakErr, longBreakErr); err != nil {
+			return err
+		}
+
+		var err error
+		duration := defaultDuration
+		switch len(positional) {
+		case 0:
+		case 1:
+			duration, err = time.ParseDuration(positional[0])
+			if err != nil || duration <= 0 {
+				return fmt.Errorf("Invalid duration %q", positional[0])
+			}
+		default:
+			return usageError("too many arguments")
+		}
+		if *cycles < 1 {
+			return fmt.Errorf("Invalid number of cycles %d", *cycles)
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		previous, err := getStatus(auth)
+		if err != nil {
+			return fmt.Errorf("Failed to get your current status: %s", err)
+		}
+
+		phases := focusPhases(duration, *cycles, *shortBreak, *longBreak, *longBreakEvery)
+		m := newFocusModel(auth, phases, *message, previous, time.Now())
+
+		// SIGINT and SIGTERM stop the program, so the status is restored below
+		// in any case.
+		final, runErr := tea.NewProgram(m).Run()
+		if fm, ok := final.(focusModel); ok {
+			m = fm
+		}
+
+		// Updates still in flight are waited for, so the status they set is
+		// compared and restored.
+		if set := m.updates.stop(); set != nil {
+			restored, err := restoreStatus(auth, *set, previous)
+			if err != nil {
+				return fmt.Errorf("Failed to restore your status: %s", err)
+			}
+			if !restored {
+				fmt.Println("Your status was changed during the session and is left as is")
+			}
+		}
+
+		if runErr != nil && runErr != tea.ErrInterrupted {
+			return fmt.Errorf("Failed to render focus session: %s", runErr)
+		}
+
+		if m.done {
+			fmt.Println("Focus session finished")
+		} else {
+			fmt.Println("Focus session aborted")
+		}
+		return nil
+	}
+}
+
+func parseFocusDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid focus duration %q in config", value)
+	}
+	return duration, nil
+}
+
+func valueOr[T comparable](value, fallback T) T {
+	var zero T
+	if value == zero {
+		return fallback
+	}
+	return value
+}
+
+type focusTickMsg time.Time
+
+type focusStatusMsg struct {
+	err error
+}
+
+// focusUpdates serializes the status updates of a focus session, which run in
+// the background, and tracks the status they set.
+type focusUpdates struct {
+	mu      sync.Mutex
+	stopped bool
+
+	// applied is the focus status set on the server or nil if the previous
+	// status is set.
+	applied *ocs.UserStatus
+}
+
+// run runs an update unless the session was stopped.
+func (u *focusUpdates) run(update func() error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.stopped {
+		return nil
+	}
+	return update()
+}
+
+// stop waits for the running update, skips all later ones and returns the
+// focus status set on the server.
+func (u *focusUpdates) stop() *ocs.UserStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.stopped = true
+	return u.applied
+}
+
+type focusModel struct {
+	auth     ocs.Auth
+	phases   []focusPhase
+	message  string
+	previous *ocs.UserStatus
+	updates  *focusUpdates
+
+	// set is the status set by the current focus session or nil during
+	// breaks.
+	set *ocs.UserStatus
+
+	index    int
+	now      time.Time
+	end      time.Time
+	pausedAt time.Time
+	done     bool
+	err      error
+}
+
+func newFocusModel(auth ocs.Auth, phases []focusPhase, message string, previous *ocs.UserStatus, now time.Time) focusModel {
+	m := focusModel{
+		auth:     auth,
+		phases:   phases,
+		message:  message,
+		previous: previous,
+		updates:  &focusUpdates{},
+		now:      now,
+	}
+	m.end = now.Add(phases[0].duration)
+	status := m.focusStatus()
+	m.set = &status
+	return m
+}
+
+func (m focusModel) Init() tea.Cmd {
+	return tea.Batch(m.apply(*m.set), focusTick())
+}
+
+func focusTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return focusTickMsg(t)
+	})
+}
+
+func (m focusModel) paused() bool {
+	return !m.pausedAt.IsZero()
+}
+
+// focusStatus returns the status of a focus session. It is not cleared while
+// the session is paused.
+func (m focusModel) focusStatus() ocs.UserStatus {
+	status := ocs.UserStatus{
+		User:    m.auth.User,
+		Status:  statusDnd,
+		Icon:    focusIcon,
+		Message: m.message,
+	}
+	if !m.paused() {
+		status.ClearAt = m.end.Unix()
+	}
+	return status
+}
+
+func (m focusModel) apply(status ocs.UserStatus) tea.Cmd {
+	auth := m.auth
+	updates := m.updates
+	return func() tea.Msg {
+		return focusStatusMsg{err: updates.run(func() error {
+			err := updateStatus(auth, status.Status, status.Message, status.Icon, status.ClearAt, false)
+			if err == nil {
+				updates.applied = &status
+			}
+			return err
+		})}
+	}
+}
+
+func (m focusModel) restore(set ocs.UserStatus) tea.Cmd {
+	auth := m.auth
+	previous := m.previous
+	updates := m.updates
+	return func() tea.Msg {
+		return focusStatusMsg{err: updates.run(func() error {
+			_, err := restoreStatus(auth, set, previous)
+			if err == nil {
+				updates.applied = nil
+			}
+			return err
+		})}
+	}
+}
+
+// refresh updates the status of a running focus session after its end
+// changed.
+func (m focusModel) refresh() (focusModel, tea.Cmd) {
+	if m.set == nil {
+		return m, nil
+	}
+
+	status := m.focusStatus()
+	m.set = &status
+	return m, m.apply(status)
+}
+
+// advance starts the next phase or finishes the session.
+func (m focusModel) advance() (focusModel, tea.Cmd) {
+	m.index++
+	if m.index >= len(m.phases) {
+		m.done = true
+		return m, tea.Quit
+	}
+
+	m.end = m.now.Add(m.phases[m.index].duration)
+	if m.phases[m.index].focus {
+		status := m.focusStatus()
+		m.set = &status
+		return m, m.apply(status)
+	}
+
+	var cmd tea.Cmd
+	if m.set != nil {
+		cmd = m.restore(*m.set)
+		m.set = nil
+	}
+	return m, cmd
+}
+
+func (m focusModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case focusTickMsg:
+		m.now = time.Time(msg)
+		if m.paused() || m.now.Before(m.end) {
+			return m, focusTick()
+		}
+
+		m, cmd := m.advance()
+		if m.done {
+			return m, cmd
+		}
+		return m, tea.Batch(cmd, focusTick())
+	case focusStatusMsg:
+		m.err = msg.err
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case " ", "p":
+			if m.paused() {
+				m.end = m.end.Add(m.now.Sub(m.pausedAt))
+				m.pausedAt = time.Time{}
+			} else {
+				m.pausedAt = m.now
+			}
+			return m.refresh()
+		case "e", "+":
+			m.end = m.end.Add(focusExtension)
+			return m.refresh()
+		case "s":
+			return m.advance()
+		}
+	}
+
+	return m, nil
+}
+
+func (m focusModel) remaining() time.Duration {
+	now := m.now
+	if m.paused() {
+		now = m.pausedAt
+	}
+	return max(0, m.end.Sub(now)).Round(time.Second)
+}
+
+func (m focusModel) View() string {
+	phase := m.phases[m.index]
+	sessions := 0
+	session := 0
+	for i, p := range m.phases {
+		if p.focus {
+			sessions++
+			if i <= m.index {
+				session = sessions
+			}
+		}
+	}
+
+	var title, clock string
+	remaining := m.remaining()
+	formatted := fmt.Sprintf("%02d:%02d", int(remaining.Minutes()), int(remaining.Seconds())%60)
+	if phase.focus {
+		title = fmt.Sprintf("%s Focus %d/%d: %s", focusIcon, session, sessions, m.message)
+		clock = focusClockStyle.Render(formatted)
+	} else {
+		title = fmt.Sprintf("☕ Break before focus %d/%d", session+1, sessions)
+		clock = focusBreakStyle.Render(formatted)
+	}
+	if m.paused() {
+		clock += focusMutedStyle.Render(" (paused)")
+	}
+
+	lines := []string{
+		focusTitleStyle.Render(title),
+		clock + " remaining",
+	}
+	if m.err != nil {
+		lines = append(lines, dashboardErrorStyle.Render(fmt.Sprintf("Failed to update your status: %s", m.err)))
+	}
+	lines = append(lines, "", focusMutedStyle.Render(fmt.Sprintf("space pause • e +%s • s skip • q abort", shortDuration(focusExtension))))
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func shortDuration(d time.Duration) string {
+	return strings.TrimSuffix(strings.TrimSuffix(d.String(), "0s"), "0m")
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFocusPhases(t *testing.T) {
+	phases := focusPhases(25*time.Minute, 4, 5*time.Minute, 15*time.Minute, 2)
+	assert.Equal(t, []focusPhase{
+		{focus: true, duration: 25 * time.Minute},
+		{duration: 5 * time.Minute},
+		{focus: true, duration: 25 * time.Minute},
+		{duration: 15 * time.Minute},
+		{focus: true, duration: 25 * time.Minute},
+		{duration: 5 * time.Minute},
+		{focus: true, duration: 25 * time.Minute},
+	}, phases)
+
+	assert.Len(t, focusPhases(25*time.Minute, 1, 5*time.Minute, 15*time.Minute, 4), 1)
+}
+
+func TestFocusModel(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	previous := &ocs.UserStatus{Status: statusOnline, Icon: "🏡", Message: "Working from home"}
+	m := newFocusModel(ocs.Auth{User: "alice"}, focusPhases(25*time.Minute, 2, 5*time.Minute, 15*time.Minute, 4), "Deep work", previous, start)
+	assert.Equal(&ocs.UserStatus{User: "alice", Status: statusDnd, Icon: "🍅", Message: "Deep work", ClearAt: start.Add(25 * time.Minute).Unix()}, m.set)
+
+	update := func(msg any) {
+		model, _ := m.Update(msg)
+		m = model.(focusModel)
+	}
+
+	// Pausing keeps the status until the session is resumed.
+	update(focusTickMsg(start.Add(10 * time.Minute)))
+	update(keyMsg(" "))
+	assert.Equal(int64(0), m.set.ClearAt)
+	update(focusTickMsg(start.Add(15 * time.Minute)))
+	update(keyMsg(" "))
+	assert.Equal(start.Add(30*time.Minute).Unix(), m.set.ClearAt)
+	assert.Equal(15*time.Minute, m.remaining())
+
+	update(keyMsg("e"))
+	assert.Equal(start.Add(35*time.Minute).Unix(), m.set.ClearAt)
+
+	// The status is restored during breaks.
+	update(focusTickMsg(start.Add(35 * time.Minute)))
+	assert.Nil(m.set)
+	assert.Contains(m.View(), "Break")
+
+	update(focusTickMsg(start.Add(40 * time.Minute)))
+	assert.Equal(start.Add(65*time.Minute).Unix(), m.set.ClearAt)
+	assert.Contains(m.View(), "Focus 2/2")
+
+	update(focusTickMsg(start.Add(65 * time.Minute)))
+	assert.True(m.done)
+}
+
+func TestFocusUpdatesAfterStop(t *testing.T) {
+	m := newFocusModel(ocs.Auth{User: "alice"}, focusPhases(25*time.Minute, 1, 0, 0, 0), "Deep work", nil, time.Now())
+	assert.Nil(t, m.updates.stop())
+
+	// Updates that did not run before the session stopped are skipped, so
+	// the server is not contacted.
+	assert.Equal(t, focusStatusMsg{}, m.apply(*m.set)())
+	assert.Nil(t, m.updates.stop())
+}
+
+func keyMsg(key string) tea.KeyMsg {
+	if key == " " {
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(key)}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
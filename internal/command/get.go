@@ -1,40 +1,141 @@
 package command
 
 import (
+	"flag"
 	"fmt"
+	"os"
+	"text/template"
 	"time"
 
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/meeting"
 	"github.com/st3iny/nextcloud-status-command/internal/ocs"
 )
 
-func RunGet() error {
-	auth, err := ocs.LoadAuth()
+// nextEventDays limits how far ahead the next event is searched.
+const nextEventDays = 7
+
+func setupGet(flags *flag.FlagSet) func(args []string) error {
+	nextEvent := flags.Bool("next-event", false, "also print your current or next meeting")
+	format := flags.String("format", "", "Go template to print the status with instead of --output (e.g. '{{.Icon}} {{.Message}}')")
+	maxAge := flags.Duration("max-age", 0, "print your cached status if it was fetched within the given duration (e.g. 1m)")
+	return func(args []string) error {
+		if len(args) > 1 {
+			return usageError("too many arguments")
+		}
+
+		var tmpl *template.Template
+		if *format != "" {
+			var err error
+			tmpl, err = template.New("format").Parse(*format)
+			if err != nil {
+				return usageError("invalid format: %s", err)
+			}
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		user := auth.User
+		var status *ocs.UserStatus
+		if len(args) == 1 && args[0] != auth.User {
+			user = args[0]
+			status, err = ocs.GetUserStatus(auth, user)
+		} else {
+			status, err = cachedStatus(auth, *maxAge)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Colleagues without a status are offline, but you are online until
+		// you set one.
+		if status == nil {
+			status = &ocs.UserStatus{
+				User:   user,
+				Status: statusOnline,
+			}
+		}
+
+		// The absence and meetings are only printed in the text output.
+		if tmpl != nil || globals.Output != outputText {
+			return writeStatus(os.Stdout, newStatusOutput(auth, *status, time.Now()), globals.Output, tmpl)
+		}
+
+		clearAt := "never"
+		if status.ClearAt > 0 {
+			clearAt = time.Unix(status.ClearAt, 0).String()
+		}
+
+		if status.Icon == "" {
+			fmt.Printf("%s (%s) %s\n", status.User, status.Status, status.Message)
+		} else {
+			fmt.Printf("%s (%s) %s %s\n", status.User, status.Status, status.Icon, status.Message)
+		}
+		fmt.Printf("clear at %s\n", clearAt)
+
+		// The absence and meetings of colleagues are not available.
+		if user != auth.User {
+			return nil
+		}
+
+		// A status served from the cache should not wait for the server.
+		// The absence is only extra information, so failing to fetch it
+		// is not fatal.
+		if *maxAge == 0 {
+			if err := printOutOfOffice(auth); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fetch your out-of-office period: %s\n", err)
+			}
+		}
+
+		if *nextEvent {
+			return printNextEvent(auth)
+		}
+		return nil
+	}
+}
+
+// printNextEvent prints the current or next meeting of the configured
+// calendars or of all calendars on the server if none are configured.
+func printNextEvent(auth ocs.Auth) error {
+	cfg, err := config.Load()
 	if err != nil {
-		return missingAuthError()
+		return fmt.Errorf("Failed to load config: %s", err)
 	}
 
-	status, err := ocs.GetStatus(auth)
+	if len(cfg.Calendars) == 0 {
+		cfg.Calendars = []config.Calendar{{Name: "Nextcloud", Source: "caldav"}}
+	}
+
+	sources, err := loadCalendars(cfg, &auth)
 	if err != nil {
 		return err
 	}
 
-	if status == nil {
-		status = &ocs.UserStatus{
-			User:   auth.User,
-			Status: "online",
-		}
+	cal, err := calendar.FromConfig(cfg, "")
+	if err != nil {
+		return err
+	}
+
+	now := cal.Now()
+	meetings, err := meeting.Upcoming(sources, now, now.AddDate(0, 0, nextEventDays))
+	if err != nil {
+		return err
 	}
 
-	clearAt := "never"
-	if status.ClearAt > 0 {
-		clearAt = time.Unix(status.ClearAt, 0).String()
+	if len(meetings) == 0 {
+		fmt.Printf("no events in the next %d days\n", nextEventDays)
+		return nil
 	}
 
-	if status.Icon == "" {
-		fmt.Printf("%s (%s) %s\n", status.User, status.Status, status.Message)
+	m := meetings[0]
+	if m.Start.After(now) {
+		fmt.Printf("next event %s at %s\n", m.Summary, cal.Format(m.Start.Unix()))
 	} else {
-		fmt.Printf("%s (%s) %s %s\n", status.User, status.Status, status.Icon, status.Message)
+		fmt.Printf("in %s until %s\n", m.Summary, cal.Format(m.End.Unix()))
 	}
-	fmt.Printf("clear at %s\n", clearAt)
 	return nil
 }
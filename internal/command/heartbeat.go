@@ -0,0 +1,72 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/heartbeat"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+func setupHeartbeat(flags *flag.FlagSet) func(args []string) error {
+	interval := flags.Duration("interval", 2*time.Minute, "interval between heartbeats")
+	awayAfter := flags.Duration("away-after", 5*time.Minute, "idle time after which you are reported as away")
+	idleCommand := flags.String("idle-command", "", "command printing your idle time in milliseconds (e.g. xprintidle), defaults to the idle time of your terminals")
+	once := flags.Bool("once", false, "send a single heartbeat and exit")
+	return func(args []string) error {
+		if err := checkPositive("interval", *interval); err != nil {
+			return err
+		}
+		if err := checkPositive("away-after", *awayAfter); err != nil {
+			return err
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		h := heartbeat.Heartbeat{
+			Auth:      auth,
+			Source:    newActivitySource(*idleCommand),
+			Interval:  *interval,
+			AwayAfter: *awayAfter,
+			OnBeat:    printHeartbeat,
+		}
+
+		if *once {
+			activity, status, err := h.Beat()
+			printHeartbeat(activity, status, err)
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return h.Run(ctx)
+	}
+}
+
+func newActivitySource(idleCommand string) heartbeat.Source {
+	if idleCommand != "" {
+		return heartbeat.CommandSource{Command: shellCommand(idleCommand)}
+	}
+
+	return heartbeat.NewTTYSource()
+}
+
+func printHeartbeat(activity string, status *ocs.UserStatus, err error) {
+	now := time.Now().Format(time.DateTime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to send heartbeat: %s\n", now, err)
+	} else if status == nil {
+		fmt.Printf("%s reported %s\n", now, activity)
+	} else {
+		fmt.Printf("%s reported %s, your status is %s\n", now, activity, status.Status)
+	}
+}
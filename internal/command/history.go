@@ -0,0 +1,184 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/preset"
+)
+
+// quickPickCount is the number of recently used emojis and messages offered
+// in the form.
+const quickPickCount = 5
+
+// quickPicks are saved presets and recently used values offered first in the
+// form.
+type quickPicks struct {
+	presets  []preset.Preset
+	emojis   []string
+	messages []string
+}
+
+func setupHistoryList(flags *flag.FlagSet) func(args []string) error {
+	since := flags.String("since", "", "only print changes since a duration ago or a date, e.g. 7d, 12h or 2026-10-01")
+	limit := flags.Int("limit", 20, "maximum number of changes to print, 0 for all")
+	tz := flags.String("tz", "", "time zone to print times in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		log, err := history.DefaultLog()
+		if err != nil {
+			return err
+		}
+
+		entries, err := log.Read()
+		if err != nil {
+			return fmt.Errorf("Failed to load history: %s", err)
+		}
+
+		if *since != "" {
+			sinceValue, err := parseSince(cal, *since)
+			if err != nil {
+				return usageError("%s", err)
+			}
+			entries = history.Since(entries, sinceValue)
+		}
+		if *limit > 0 && len(entries) > *limit {
+			entries = entries[len(entries)-*limit:]
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No status changes")
+			return nil
+		}
+
+		for _, entry := range entries {
+			fmt.Printf("%d\t%s\t%s\n", entry.Id, cal.Format(entry.At.Unix()), describeHistory(cal, entry))
+		}
+		return nil
+	}
+}
+
+func setupHistoryApply(flags *flag.FlagSet) func(args []string) error {
+	timeoutKey := flags.String("timeout", "", "timeout after which to delete your status, defaults to the duration of the original timeout")
+	tz := flags.String("tz", "", "time zone to resolve the timeout in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	return func(args []string) error {
+		if len(args) != 1 {
+			return usageError("expected the id of a history entry")
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return usageError("invalid id %q", args[0])
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		log, err := history.DefaultLog()
+		if err != nil {
+			return err
+		}
+
+		entry, err := log.Get(id)
+		if err != nil {
+			return err
+		}
+
+		status := entry.Status
+		if status.Status == "" {
+			status.Status = statusOnline
+		}
+
+		// The original timeout has most likely passed, so it is applied
+		// relative to now.
+		now := cal.Now()
+		status.ClearAt = 0
+		if *timeoutKey != "" {
+			status.ClearAt, err = cal.ParseTimeout(*timeoutKey, now)
+			if err != nil {
+				return err
+			}
+		} else if entry.Status.ClearAt > 0 {
+			status.ClearAt = now.Add(time.Unix(entry.Status.ClearAt, 0).Sub(entry.At)).Unix()
+		}
+
+		if err := updateStatus(auth, status.Status, status.Message, status.Icon, status.ClearAt, false); err != nil {
+			return fmt.Errorf("Failed to update your status: %s", err)
+		}
+
+		fmt.Printf("Your status is %s\n", describeStatus(cal, status))
+		return nil
+	}
+}
+
+func describeHistory(cal calendar.Calendar, entry history.Entry) string {
+	description := describeStatus(cal, entry.Status)
+	if entry.Status.Status == "" {
+		description = strings.TrimSpace("cleared message" + description)
+	}
+	if entry.Source == history.SourceObserved {
+		description += " (observed)"
+	}
+	return description
+}
+
+// parseSince parses a duration ago (e.g. 7d or 12h) or a date.
+func parseSince(cal calendar.Calendar, value string) (time.Time, error) {
+	now := cal.Now()
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, value, now.Location()); err == nil {
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected a duration like 7d or 12h or a date like 2026-10-01", value)
+}
+
+// loadQuickPicks returns the saved presets and recently used emojis and
+// messages. The history is best effort, so errors are ignored.
+func loadQuickPicks() quickPicks {
+	picks := quickPicks{presets: loadPresets()}
+
+	log, err := history.DefaultLog()
+	if err != nil {
+		return picks
+	}
+
+	entries, err := log.Read()
+	if err != nil {
+		return picks
+	}
+
+	picks.emojis = history.RecentEmojis(entries, quickPickCount)
+	picks.messages = history.RecentMessages(entries, quickPickCount)
+	return picks
+}
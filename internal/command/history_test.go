@@ -0,0 +1,43 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSince(t *testing.T) {
+	assert := assert.New(t)
+	cal := calendar.Default()
+	cal.Location = time.UTC
+
+	since, err := parseSince(cal, "7d")
+	assert.NoError(err)
+	assert.WithinDuration(time.Now().AddDate(0, 0, -7), since, time.Second)
+
+	since, err = parseSince(cal, "12h")
+	assert.NoError(err)
+	assert.WithinDuration(time.Now().Add(-12*time.Hour), since, time.Second)
+
+	since, err = parseSince(cal, "2026-10-01")
+	assert.NoError(err)
+	assert.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), since)
+
+	_, err = parseSince(cal, "last week")
+	assert.Error(err)
+	_, err = parseSince(cal, "-1d")
+	assert.Error(err)
+}
+
+func TestDescribeHistory(t *testing.T) {
+	assert := assert.New(t)
+	cal := calendar.Default()
+
+	assert.Equal("dnd 🎧 Focus", describeHistory(cal, history.Entry{Source: history.SourceNsc, Status: ocs.UserStatus{Status: statusDnd, Icon: "🎧", Message: "Focus"}}))
+	assert.Equal("away (observed)", describeHistory(cal, history.Entry{Source: history.SourceObserved, Status: ocs.UserStatus{Status: statusAway}}))
+	assert.Equal("cleared message", describeHistory(cal, history.Entry{Source: history.SourceNsc}))
+}
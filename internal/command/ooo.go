@@ -0,0 +1,219 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+func runOooGet() error {
+	auth, err := loadOooAuth()
+	if err != nil {
+		return err
+	}
+
+	ooo, err := ocs.GetOutOfOffice(auth)
+	if err != nil {
+		return err
+	}
+
+	if ooo == nil {
+		fmt.Println("No out-of-office period is set")
+		return nil
+	}
+
+	fmt.Printf("Out of office from %s to %s: %s\n", formatDay(ooo.FirstDay), formatDay(ooo.LastDay), ooo.Status)
+	if ooo.Message != "" {
+		fmt.Println(ooo.Message)
+	}
+	return nil
+}
+
+func setupOooSet(flags *flag.FlagSet) func(args []string) error {
+	from := flags.String("from", "today", "first day of your absence (e.g. tomorrow, monday or 2026-12-22)")
+	to := flags.String("to", "", "last day of your absence")
+	status := flags.String("status", "Out of office", "short message shown as your status")
+	message := flags.String("message", "", "long message shown to people trying to reach you")
+	return func(args []string) error {
+		if *to == "" || *message == "" {
+			return usageError("--to and --message are required")
+		}
+
+		cal, err := calendar.Load("")
+		if err != nil {
+			return err
+		}
+
+		now := cal.Now()
+		firstDay, err := parseDay(cal, *from, now)
+		if err != nil {
+			return err
+		}
+		lastDay, err := parseDay(cal, *to, now)
+		if err != nil {
+			return err
+		}
+		if lastDay.Before(firstDay) {
+			return fmt.Errorf("The last day of your absence is before the first one")
+		}
+
+		auth, err := loadOooAuth()
+		if err != nil {
+			return err
+		}
+
+		ooo := ocs.OutOfOffice{
+			FirstDay: firstDay.Format(time.DateOnly),
+			LastDay:  lastDay.Format(time.DateOnly),
+			Status:   *status,
+			Message:  *message,
+		}
+		if err := ocs.SetOutOfOffice(auth, ooo); err != nil {
+			return err
+		}
+
+		fmt.Printf("Out of office from %s to %s\n", formatDay(ooo.FirstDay), formatDay(ooo.LastDay))
+		return nil
+	}
+}
+
+func runOooClear() error {
+	auth, err := loadOooAuth()
+	if err != nil {
+		return err
+	}
+
+	return ocs.ClearOutOfOffice(auth)
+}
+
+// loadOooAuth loads the credentials and checks that the server supports
+// out-of-office periods.
+func loadOooAuth() (ocs.Auth, error) {
+	auth, err := ocs.LoadAuth()
+	if err != nil {
+		return ocs.Auth{}, missingAuthError()
+	}
+
+	supported, err := supportsOutOfOffice(auth)
+	if err != nil {
+		return ocs.Auth{}, err
+	}
+	if !supported {
+		return ocs.Auth{}, fmt.Errorf("Your Nextcloud server does not support out-of-office periods")
+	}
+
+	return auth, nil
+}
+
+// outOfOfficeTTL is how long the support of the server for out-of-office
+// periods is cached.
+const outOfOfficeTTL = 24 * time.Hour
+
+// supportsOutOfOffice reports whether the server supports out-of-office
+// periods. The capability rarely changes, so it is cached.
+func supportsOutOfOffice(auth ocs.Auth) (bool, error) {
+	name := "nsc/out-of-office.json"
+	if profile := ocs.Profile(); profile != "" {
+		name = filepath.Join("nsc", "profiles", profile, "out-of-office.json")
+	}
+
+	cachePath, err := xdg.CacheFile(name)
+	if err != nil {
+		return ocs.SupportsOutOfOffice(auth)
+	}
+
+	if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < outOfOfficeTTL {
+		var supported bool
+		if data, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(data, &supported) == nil {
+			return supported, nil
+		}
+	}
+
+	supported, err := ocs.SupportsOutOfOffice(auth)
+	if err != nil {
+		return false, err
+	}
+
+	if data, err := json.Marshal(supported); err == nil {
+		os.WriteFile(cachePath, data, 0600)
+	}
+	return supported, nil
+}
+
+// printOutOfOffice prints an active or upcoming absence if the server
+// supports them.
+func printOutOfOffice(auth ocs.Auth) error {
+	supported, err := supportsOutOfOffice(auth)
+	if err != nil || !supported {
+		return err
+	}
+
+	ooo, err := ocs.GetOutOfOffice(auth)
+	if err != nil {
+		return err
+	}
+
+	cal, err := calendar.Load("")
+	if err != nil {
+		return err
+	}
+
+	if description := describeOutOfOffice(ooo, cal.Day(cal.Now())); description != "" {
+		fmt.Println(description)
+	}
+	return nil
+}
+
+// describeOutOfOffice describes an active or upcoming absence. Past ones
+// are described as an empty string.
+func describeOutOfOffice(ooo *ocs.OutOfOffice, today time.Time) string {
+	day := today.Format(time.DateOnly)
+	switch {
+	case ooo == nil || ooo.LastDay < day:
+		return ""
+	case ooo.FirstDay <= day:
+		return fmt.Sprintf("out of office until %s (%s)", formatDay(ooo.LastDay), ooo.Status)
+	default:
+		return fmt.Sprintf("out of office from %s to %s (%s)", formatDay(ooo.FirstDay), formatDay(ooo.LastDay), ooo.Status)
+	}
+}
+
+func formatDay(day string) string {
+	t, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return day
+	}
+	return t.Format("Mon, 02 Jan 2006")
+}
+
+// parseDay resolves a day like "today", "tomorrow", "monday" or "2026-12-22"
+// to the start of that day. Weekdays refer to the next such day, which may be
+// today. Unlike timeouts, days may lie in the past.
+func parseDay(cal calendar.Calendar, expr string, now time.Time) (time.Time, error) {
+	today := cal.Day(now)
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	switch expr {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if weekday, ok := calendar.ParseWeekday(expr); ok {
+		return today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7), nil
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, expr, cal.Location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid day %q, expected e.g. tomorrow, monday or 2026-12-22", expr)
+	}
+	return day, nil
+}
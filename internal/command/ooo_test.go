@@ -0,0 +1,71 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDay(t *testing.T) {
+	cal := calendar.Default()
+	cal.Location = time.UTC
+
+	// Monday
+	now := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	for expr, expected := range map[string]time.Time{
+		"today":      time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		"tomorrow":   time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+		"friday":     time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC),
+		"Mon":        time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		"2026-12-22": time.Date(2026, 12, 22, 0, 0, 0, 0, time.UTC),
+		"2026-10-19": time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		"2026-10-01": time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		day, err := parseDay(cal, expr, now)
+		require.NoError(t, err, expr)
+		assert.Equal(t, expected, day, expr)
+	}
+
+	for _, expr := range []string{"soon", "2026-13-01", "17:00"} {
+		_, err := parseDay(cal, expr, now)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestDescribeOutOfOffice(t *testing.T) {
+	ooo := &ocs.OutOfOffice{FirstDay: "2026-12-22", LastDay: "2027-01-02", Status: "Vacation"}
+
+	assert.Equal(t, "out of office from Tue, 22 Dec 2026 to Sat, 02 Jan 2027 (Vacation)", describeOutOfOffice(ooo, time.Date(2026, 12, 21, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "out of office until Sat, 02 Jan 2027 (Vacation)", describeOutOfOffice(ooo, time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC)))
+	assert.Empty(t, describeOutOfOffice(ooo, time.Date(2027, 1, 3, 0, 0, 0, 0, time.UTC)))
+	assert.Empty(t, describeOutOfOffice(nil, time.Date(2027, 1, 3, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestSupportsOutOfOfficeIsCached(t *testing.T) {
+	t.Setenv("NSC_PROFILE", "")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xdg.Reload()
+	defer xdg.Reload()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"ocs": {"data": {"capabilities": {"dav": {"absence-supported": true}}}}}`))
+	}))
+	defer server.Close()
+	auth := ocs.Auth{ServerBaseUrl: server.URL, User: "alice"}
+
+	for range 2 {
+		supported, err := supportsOutOfOffice(auth)
+		require.NoError(t, err)
+		assert.True(t, supported)
+	}
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,137 @@
+package command
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputYaml = "yaml"
+	outputTsv  = "tsv"
+	outputCsv  = "csv"
+	outputEnv  = "env"
+)
+
+// statusOutput is the machine-readable status printed by nsc get. The schema
+// is documented in the README and must stay stable.
+type statusOutput struct {
+	User                string `json:"user" yaml:"user"`
+	Status              string `json:"status" yaml:"status"`
+	Icon                string `json:"icon" yaml:"icon"`
+	Message             string `json:"message" yaml:"message"`
+	MessageId           string `json:"messageId" yaml:"messageId"`
+	MessageIsPredefined bool   `json:"messageIsPredefined" yaml:"messageIsPredefined"`
+
+	// ClearAt is nil if the status message is not cleared automatically.
+	ClearAt          *time.Time `json:"clearAt" yaml:"clearAt"`
+	RemainingSeconds int64      `json:"remainingSeconds" yaml:"remainingSeconds"`
+
+	Server  string `json:"server" yaml:"server"`
+	Profile string `json:"profile" yaml:"profile"`
+}
+
+func newStatusOutput(auth ocs.Auth, status ocs.UserStatus, now time.Time) statusOutput {
+	output := statusOutput{
+		User:                status.User,
+		Status:              status.Status,
+		Icon:                status.Icon,
+		Message:             status.Message,
+		MessageId:           status.MessageId,
+		MessageIsPredefined: status.MessageIsPredefined,
+		Server:              auth.ServerBaseUrl,
+		Profile:             ocs.Profile(),
+	}
+	if status.ClearAt > 0 {
+		clearAt := time.Unix(status.ClearAt, 0).In(now.Location())
+		output.ClearAt = &clearAt
+		output.RemainingSeconds = max(0, status.ClearAt-now.Unix())
+	}
+	return output
+}
+
+// fields returns the names and values of the output in the documented order.
+func (o statusOutput) fields() ([]string, []string) {
+	clearAt := ""
+	if o.ClearAt != nil {
+		clearAt = o.ClearAt.Format(time.RFC3339)
+	}
+
+	names := []string{"user", "status", "icon", "message", "messageId", "messageIsPredefined", "clearAt", "remainingSeconds", "server", "profile"}
+	values := []string{
+		o.User,
+		o.Status,
+		o.Icon,
+		o.Message,
+		o.MessageId,
+		strconv.FormatBool(o.MessageIsPredefined),
+		clearAt,
+		strconv.FormatInt(o.RemainingSeconds, 10),
+		o.Server,
+		o.Profile,
+	}
+	return names, values
+}
+
+// writeStatus prints the status with the template or in the output format.
+func writeStatus(w io.Writer, output statusOutput, format string, tmpl *template.Template) error {
+	if tmpl != nil {
+		if err := tmpl.Execute(w, output); err != nil {
+			return fmt.Errorf("Failed to print your status: %s", err)
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+
+	switch format {
+	case outputJson:
+		return json.NewEncoder(w).Encode(output)
+	case outputYaml:
+		return yaml.NewEncoder(w).Encode(output)
+	case outputTsv:
+		_, values := output.fields()
+		for i, value := range values {
+			values[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(value)
+		}
+		_, err := fmt.Fprintln(w, strings.Join(values, "\t"))
+		return err
+	case outputCsv:
+		names, values := output.fields()
+		return csv.NewWriter(w).WriteAll([][]string{names, values})
+	case outputEnv:
+		names, values := output.fields()
+		for i, name := range names {
+			if _, err := fmt.Fprintf(w, "NSC_%s=%s\n", envName(name), shellQuote(values[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Unsupported output format %q", format)
+}
+
+// envName converts a camel case name to upper snake case, e.g. "clearAt" to
+// "CLEAR_AT".
+func envName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteRune('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteStatus(t *testing.T) {
+	t.Setenv("NSC_PROFILE", "work")
+
+	loc := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, loc)
+	auth := ocs.Auth{ServerBaseUrl: "https://cloud.example.com", User: "alice"}
+	status := ocs.UserStatus{
+		User:                "alice",
+		Status:              statusDnd,
+		Icon:                "📅",
+		Message:             "In a meeting",
+		ClearAt:             now.Add(90 * time.Minute).Unix(),
+		MessageId:           "meeting",
+		MessageIsPredefined: true,
+	}
+	output := newStatusOutput(auth, status, now)
+
+	write := func(format string, tmpl *template.Template) string {
+		var out bytes.Buffer
+		require.NoError(t, writeStatus(&out, output, format, tmpl))
+		return out.String()
+	}
+
+	assert.JSONEq(t, `{
+		"user": "alice",
+		"status": "dnd",
+		"icon": "📅",
+		"message": "In a meeting",
+		"messageId": "meeting",
+		"messageIsPredefined": true,
+		"clearAt": "2026-10-19T13:30:00+02:00",
+		"remainingSeconds": 5400,
+		"server": "https://cloud.example.com",
+		"profile": "work"
+	}`, write(outputJson, nil))
+	assert.Contains(t, write(outputYaml, nil), "clearAt: 2026-10-19T13:30:00+02:00\nremainingSeconds: 5400\n")
+	assert.Equal(t, "alice\tdnd\t📅\tIn a meeting\tmeeting\ttrue\t2026-10-19T13:30:00+02:00\t5400\thttps://cloud.example.com\twork\n", write(outputTsv, nil))
+	assert.Equal(t, "user,status,icon,message,messageId,messageIsPredefined,clearAt,remainingSeconds,server,profile\nalice,dnd,📅,In a meeting,meeting,true,2026-10-19T13:30:00+02:00,5400,https://cloud.example.com,work\n", write(outputCsv, nil))
+	assert.Contains(t, write(outputEnv, nil), "NSC_MESSAGE='In a meeting'\nNSC_MESSAGE_ID='meeting'\nNSC_MESSAGE_IS_PREDEFINED='true'\n")
+	assert.Equal(t, "📅 In a meeting\n", write(outputText, template.Must(template.New("").Parse("{{.Icon}} {{.Message}}"))))
+
+	// Statuses without timeout are never cleared.
+	output = newStatusOutput(auth, ocs.UserStatus{User: "alice", Status: statusOnline, Message: "It's fine"}, now)
+	assert.Contains(t, write(outputJson, nil), `"clearAt":null,"remainingSeconds":0`)
+	assert.Contains(t, write(outputEnv, nil), `NSC_MESSAGE='It'\''s fine'`)
+}
@@ -0,0 +1,182 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/emoji"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/preset"
+)
+
+func setupPresetAdd(flags *flag.FlagSet) func(args []string) error {
+	statusValue := flags.String("status", statusOnline, fmt.Sprintf(
+		"the status to set [options: %s]",
+		strings.Join(statuses, ", "),
+	))
+	emojiValue := flags.String("emoji", "", "the status emoji or its shortcode (e.g. :headphone:)")
+	messageValue := flags.String("message", "", "the status message, may use {{.Until}}, {{.Now}}, {{.Date}} and {{.Weekday}}")
+	timeoutKey := flags.String("timeout", "", "timeout after which to delete the status, resolved when the preset is applied (e.g. 1h or 17:30)")
+	force := flags.Bool("force", false, "replace an existing preset with the same name")
+	return func(args []string) error {
+		if len(args) != 1 {
+			return usageError("expected the name of the preset")
+		}
+		if err := checkStatus(*statusValue); err != nil {
+			return err
+		}
+
+		p := preset.Preset{
+			Name:    args[0],
+			Status:  *statusValue,
+			Icon:    emoji.Resolve(*emojiValue),
+			Message: *messageValue,
+			Timeout: *timeoutKey,
+		}
+
+		// Catch invalid timeouts and templates now rather than when the
+		// preset is applied.
+		cal, err := calendar.Load("")
+		if err != nil {
+			return err
+		}
+		if _, err := p.Resolve(cal, cal.Now()); err != nil {
+			return err
+		}
+
+		store, err := preset.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		if err := store.Add(p, *force); err != nil {
+			return err
+		}
+
+		fmt.Printf("Saved preset %s, apply it with \"nsc set %s\"\n", p.Name, p.Name)
+		return nil
+	}
+}
+
+func setupPresetList(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+
+		store, err := preset.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		presets, err := store.List()
+		if err != nil {
+			return fmt.Errorf("Failed to load presets: %s", err)
+		}
+
+		if len(presets) == 0 {
+			fmt.Println("No presets")
+			return nil
+		}
+
+		for _, p := range presets {
+			fmt.Printf("%s\t%s\n", p.Name, describePreset(p))
+		}
+		return nil
+	}
+}
+
+func setupPresetRemove(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 1 {
+			return usageError("expected the name of the preset")
+		}
+
+		store, err := preset.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		return store.Remove(args[0])
+	}
+}
+
+func setupSet(flags *flag.FlagSet) func(args []string) error {
+	tz := flags.String("tz", "", "time zone to resolve the timeout in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	queueEnabled := addQueueFlag(flags)
+	return func(args []string) error {
+		if len(args) != 1 {
+			return usageError("expected the name of a preset")
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		store, err := preset.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		p, err := store.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		status, err := p.Resolve(cal, cal.Now())
+		if err != nil {
+			return err
+		}
+		status.User = auth.User
+
+		err = updateStatus(auth, status.Status, status.Message, status.Icon, status.ClearAt, false)
+		if err != nil && queueEnabled() {
+			return queueChange(err, false, status)
+		} else if err != nil {
+			return fmt.Errorf("Failed to update your status: %s", err)
+		}
+
+		fmt.Printf("Your status is %s\n", describeStatus(cal, status))
+		return nil
+	}
+}
+
+// describePreset describes a preset with its unresolved message and timeout.
+func describePreset(p preset.Preset) string {
+	description := p.Status
+	if p.Icon != "" {
+		description += " " + p.Icon
+	}
+	if p.Message != "" {
+		description += " " + p.Message
+	}
+	if p.Timeout != "" && p.Timeout != calendar.PresetNever {
+		description += " (" + p.Timeout + ")"
+	}
+
+	return description
+}
+
+// loadPresets returns the saved presets. Presets are optional in the form, so
+// errors are ignored.
+func loadPresets() []preset.Preset {
+	store, err := preset.DefaultStore()
+	if err != nil {
+		return nil
+	}
+
+	presets, err := store.List()
+	if err != nil {
+		return nil
+	}
+
+	return presets
+}
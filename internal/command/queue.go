@@ -0,0 +1,190 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/queue"
+)
+
+func setupQueueList(flags *flag.FlagSet) func(args []string) error {
+	tz := flags.String("tz", "", "time zone to print times in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		store, err := queue.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		entries, err := store.List()
+		if err != nil {
+			return fmt.Errorf("Failed to load queue: %s", err)
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No queued status changes")
+			return nil
+		}
+
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s\t%s\n", entry.Id, cal.Format(entry.QueuedAt), describeQueued(cal, entry))
+		}
+		return nil
+	}
+}
+
+func setupQueueFlush(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		runner, err := newQueueRunner(auth)
+		if err != nil {
+			return err
+		}
+
+		applied, err := runner.Flush(time.Now())
+		if applied > 0 {
+			fmt.Printf("Applied %d queued status changes\n", applied)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to apply queued status changes: %s", err)
+		}
+		return nil
+	}
+}
+
+func setupQueueDrop(flags *flag.FlagSet) func(args []string) error {
+	all := flags.Bool("all", false, "drop all queued status changes")
+	return func(args []string) error {
+		if *all && len(args) > 0 {
+			return usageError("expected either --all or ids of queued changes")
+		} else if !*all && len(args) == 0 {
+			return usageError("expected the id of a queued change")
+		}
+
+		store, err := queue.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		if *all {
+			return store.DropAll()
+		}
+
+		for _, id := range args {
+			if err := store.Drop(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func describeQueued(cal calendar.Calendar, entry queue.Entry) string {
+	if entry.Clear {
+		return "clear message"
+	}
+	return describeStatus(cal, entry.Status)
+}
+
+// newQueueRunner returns a runner that applies queued changes through the
+// daemon if it is running.
+func newQueueRunner(auth ocs.Auth) (*queue.Runner, error) {
+	store, err := queue.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return &queue.Runner{
+		Store: store,
+		Apply: func(status ocs.UserStatus) error {
+			return updateStatus(auth, status.Status, status.Message, status.Icon, status.ClearAt, false)
+		},
+		Clear: func() error {
+			return clearStatus(auth)
+		},
+	}, nil
+}
+
+// FlushQueue applies status changes that were queued while the server was
+// not reachable. Nothing is reported if it is still not reachable.
+func FlushQueue() error {
+	auth, err := ocs.LoadAuth()
+	if err != nil {
+		// Nothing can be applied before logging in.
+		return nil
+	}
+
+	runner, err := newQueueRunner(auth)
+	if err != nil {
+		return err
+	}
+
+	applied, err := runner.Flush(time.Now())
+	if applied > 0 {
+		// Keep the output of the command itself clean, e.g. for bars.
+		fmt.Fprintf(os.Stderr, "Applied %d status changes queued while offline\n", applied)
+	}
+	if err != nil && !ocs.IsTransportError(err) {
+		return fmt.Errorf("Failed to apply queued status changes: %s", err)
+	}
+	return nil
+}
+
+// addQueueFlag adds the --queue flag. Unless it is given, the offlineQueue
+// setting of the config decides once the command runs.
+func addQueueFlag(flags *flag.FlagSet) func() bool {
+	enabled := flags.Bool("queue", false, "queue the change if the server is not reachable and apply it once it is (defaults to offlineQueue of the config)")
+	return func() bool {
+		set := false
+		flags.Visit(func(f *flag.Flag) {
+			set = set || f.Name == "queue"
+		})
+		if set {
+			return *enabled
+		}
+
+		cfg, err := config.Load()
+		return err == nil && cfg.OfflineQueue
+	}
+}
+
+// queueChange queues a status change that failed because the server was not
+// reachable. Other errors are returned as is.
+func queueChange(err error, clear bool, status ocs.UserStatus) error {
+	if !ocs.IsTransportError(err) {
+		return err
+	}
+
+	store, storeErr := queue.DefaultStore()
+	if storeErr != nil {
+		return err
+	}
+
+	if _, storeErr := store.Add(time.Now(), clear, status); storeErr != nil {
+		return fmt.Errorf("Failed to queue status change: %s", storeErr)
+	}
+
+	fmt.Println("The server is not reachable, so the change is applied once it is again (see nsc queue list)")
+	return nil
+}
@@ -0,0 +1,193 @@
+package command
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/report"
+	"gopkg.in/yaml.v3"
+)
+
+func setupReport(flags *flag.FlagSet) func(args []string) error {
+	week := flags.Bool("week", false, "report on the current week (the default)")
+	lastWeek := flags.Bool("last-week", false, "report on the previous week")
+	since := flags.String("since", "", "report since a duration ago or a date, e.g. 30d or 2026-10-01")
+	tz := flags.String("tz", "", "time zone of the days (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unexpected argument %q", args[0])
+		}
+
+		periods := 0
+		for _, set := range []bool{*week, *lastWeek, *since != ""} {
+			if set {
+				periods++
+			}
+		}
+		if periods > 1 {
+			return usageError("--week, --last-week and --since are mutually exclusive")
+		}
+		if globals.Output == outputEnv {
+			return usageError("the report cannot be printed as %s", globals.Output)
+		}
+
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		now := cal.Now()
+		from, to := cal.StartOfWeek(now), now
+		if *lastWeek {
+			to = from
+			from = cal.StartOfWeek(from.Add(-time.Second))
+		} else if *since != "" {
+			from, err = parseSince(cal, *since)
+			if err != nil {
+				return usageError("%s", err)
+			}
+		}
+
+		log, err := history.DefaultLog()
+		if err != nil {
+			return err
+		}
+
+		entries, err := log.Read()
+		if err != nil {
+			return fmt.Errorf("Failed to load history: %s", err)
+		}
+
+		return writeReport(os.Stdout, report.New(cal, entries, from, to), globals.Output)
+	}
+}
+
+// reportOutput is the machine-readable report printed by nsc report.
+type reportOutput struct {
+	From     time.Time        `json:"from" yaml:"from"`
+	To       time.Time        `json:"to" yaml:"to"`
+	Statuses []durationOutput `json:"statuses" yaml:"statuses"`
+	Messages []durationOutput `json:"messages" yaml:"messages"`
+	Days     []dayOutput      `json:"days" yaml:"days"`
+}
+
+type durationOutput struct {
+	Name    string `json:"name" yaml:"name"`
+	Seconds int64  `json:"seconds" yaml:"seconds"`
+}
+
+type dayOutput struct {
+	Date     string           `json:"date" yaml:"date"`
+	Statuses []durationOutput `json:"statuses" yaml:"statuses"`
+	Messages []durationOutput `json:"messages" yaml:"messages"`
+}
+
+func newReportOutput(r report.Report) reportOutput {
+	output := reportOutput{
+		From:     r.From,
+		To:       r.To,
+		Statuses: durationOutputs(r.Statuses()),
+		Messages: durationOutputs(r.Messages()),
+		Days:     []dayOutput{},
+	}
+	for _, day := range r.Days {
+		output.Days = append(output.Days, dayOutput{
+			Date:     day.Date.Format(time.DateOnly),
+			Statuses: durationOutputs(day.Statuses),
+			Messages: durationOutputs(day.Messages),
+		})
+	}
+	return output
+}
+
+func durationOutputs(totals map[string]time.Duration) []durationOutput {
+	outputs := []durationOutput{}
+	for _, name := range report.ByDuration(totals) {
+		outputs = append(outputs, durationOutput{Name: name, Seconds: int64(totals[name].Seconds())})
+	}
+	return outputs
+}
+
+func writeReport(w io.Writer, r report.Report, format string) error {
+	output := newReportOutput(r)
+
+	switch format {
+	case outputText:
+		return writeReportTable(w, r)
+	case outputJson:
+		return json.NewEncoder(w).Encode(output)
+	case outputYaml:
+		return yaml.NewEncoder(w).Encode(output)
+	case outputCsv, outputTsv:
+		// One row per day and status or message, so it can be pivoted in a
+		// spreadsheet.
+		writer := csv.NewWriter(w)
+		if format == outputTsv {
+			writer.Comma = '\t'
+		}
+		writer.Write([]string{"date", "kind", "name", "seconds"})
+		for _, day := range output.Days {
+			for _, status := range day.Statuses {
+				writer.Write([]string{day.Date, "status", status.Name, strconv.FormatInt(status.Seconds, 10)})
+			}
+			for _, message := range day.Messages {
+				writer.Write([]string{day.Date, "message", message.Name, strconv.FormatInt(message.Seconds, 10)})
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	return fmt.Errorf("Unsupported output format %q", format)
+}
+
+func writeReportTable(w io.Writer, r report.Report) error {
+	statuses := r.Statuses()
+	if len(statuses) == 0 {
+		_, err := fmt.Fprintln(w, "No status changes recorded in this period")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s - %s\n\n", r.From.Format("Mon, 02 Jan 2006 15:04"), r.To.Format("Mon, 02 Jan 2006 15:04"))
+
+	names := report.ByDuration(statuses)
+	fmt.Fprintln(tw, "STATUS\tTIME")
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%s\n", name, formatHours(statuses[name]))
+	}
+
+	if messages := r.Messages(); len(messages) > 0 {
+		fmt.Fprintln(tw, "\nMESSAGE\tTIME")
+		for _, message := range report.ByDuration(messages) {
+			fmt.Fprintf(tw, "%s\t%s\n", message, formatHours(messages[message]))
+		}
+	}
+
+	fmt.Fprintf(tw, "\nDAY\t%s\n", strings.ToUpper(strings.Join(names, "\t")))
+	for _, day := range r.Days {
+		fmt.Fprint(tw, day.Date.Format("Mon 02 Jan"))
+		for _, name := range names {
+			fmt.Fprintf(tw, "\t%s", formatHours(day.Statuses[name]))
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// formatHours formats a duration as hours and minutes, e.g. 12:30.
+func formatHours(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
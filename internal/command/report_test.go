@@ -0,0 +1,58 @@
+package command
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteReport(t *testing.T) {
+	cal := calendar.Default()
+	cal.Location = time.UTC
+
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	entries := []history.Entry{
+		{At: monday.Add(9 * time.Hour), Status: ocs.UserStatus{Status: statusOnline}},
+		{At: monday.Add(10 * time.Hour), Status: ocs.UserStatus{Status: statusDnd, Icon: "🎧", Message: "Focus", ClearAt: monday.Add(12 * time.Hour).Unix()}},
+		{At: monday.Add(17 * time.Hour), Status: ocs.UserStatus{Status: statusInvisible}},
+	}
+	r := report.New(cal, entries, monday, monday.Add(30*time.Hour))
+
+	write := func(format string) string {
+		var out bytes.Buffer
+		require.NoError(t, writeReport(&out, r, format))
+		return out.String()
+	}
+
+	assert.Equal(t, `Mon, 19 Oct 2026 00:00 - Tue, 20 Oct 2026 06:00
+
+STATUS     TIME
+invisible  13:00
+dnd        7:00
+online     1:00
+
+MESSAGE  TIME
+🎧 Focus  2:00
+
+DAY         INVISIBLE  DND   ONLINE
+Mon 19 Oct  7:00       7:00  1:00
+Tue 20 Oct  6:00       0:00  0:00
+`, write(outputText))
+
+	assert.Equal(t, `date,kind,name,seconds
+2026-10-19,status,dnd,25200
+2026-10-19,status,invisible,25200
+2026-10-19,status,online,3600
+2026-10-19,message,🎧 Focus,7200
+2026-10-20,status,invisible,21600
+`, write(outputCsv))
+
+	assert.Contains(t, write(outputJson), `"statuses":[{"name":"invisible","seconds":46800},{"name":"dnd","seconds":25200},{"name":"online","seconds":3600}]`)
+}
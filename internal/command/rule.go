@@ -0,0 +1,124 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/rules"
+	"github.com/st3iny/nextcloud-status-command/internal/schedule"
+)
+
+func setupRuleList(flags *flag.FlagSet) func(args []string) error {
+	count := flags.Int("count", 3, "number of upcoming occurrences to print per rule")
+	return func(args []string) error {
+		runner, err := newRulesRunner(nil)
+		if err != nil {
+			return err
+		}
+
+		if len(runner.Rules) == 0 {
+			fmt.Println("No rules are configured")
+			return nil
+		}
+
+		cal := runner.Calendar
+		for _, rule := range runner.Rules {
+			fmt.Printf("%s: %s (timeout %s)\n", rule.Name, describeStatus(cal, rule.Status), rule.Timeout)
+
+			occurrences, err := runner.Upcoming(rule.Name, cal.Now(), *count)
+			if err != nil {
+				return err
+			}
+			for _, occurrence := range occurrences {
+				var notes []string
+				if occurrence.Holiday {
+					notes = append(notes, "holiday")
+				}
+				if occurrence.Skipped {
+					notes = append(notes, "skipped")
+				}
+
+				line := "  " + cal.Format(occurrence.At.Unix())
+				if len(notes) > 0 {
+					line += fmt.Sprintf(" (%s)", strings.Join(notes, ", "))
+				}
+				fmt.Println(line)
+			}
+		}
+
+		return nil
+	}
+}
+
+func setupRuleSkip(flags *flag.FlagSet) func(args []string) error {
+	on := flags.String("on", "", "skip the first occurrence on this day instead of the next one (e.g. tomorrow, friday or 2026-10-20)")
+	return func(positional []string) error {
+		if len(positional) != 1 {
+			return usageError("expected the name of a rule")
+		}
+
+		runner, err := newRulesRunner(nil)
+		if err != nil {
+			return err
+		}
+
+		cal := runner.Calendar
+		now := cal.Now()
+		from := now
+		if *on != "" {
+			day, err := parseDay(cal, *on, now)
+			if err != nil {
+				return err
+			}
+			from = cal.StartOfDay(day).Add(-time.Second)
+			if from.Before(now) {
+				from = now
+			}
+		}
+
+		at, err := runner.Skip(positional[0], from)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Skipping %s on %s\n", positional[0], cal.Format(at.Unix()))
+		return nil
+	}
+}
+
+// newRulesRunner returns a runner for the configured rules that applies their
+// occurrences with apply.
+func newRulesRunner(apply func(ocs.UserStatus) error) (*rules.Runner, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load config: %s", err)
+	}
+
+	cal, err := calendar.FromConfig(cfg, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := rules.Load(cfg, cal)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := rules.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rules.Runner{
+		Rules:    rs,
+		Calendar: cal,
+		Store:    store,
+		Grace:    schedule.DefaultGrace,
+		Apply:    apply,
+	}, nil
+}
@@ -0,0 +1,193 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/daemon"
+	"github.com/st3iny/nextcloud-status-command/internal/emoji"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/schedule"
+)
+
+func setupSchedule(flags *flag.FlagSet) func(args []string) error {
+	at := flags.String("at", "", "when to change your status, e.g. \"2026-10-20 12:00\", 12:00 or tomorrow 9am")
+	statusValue := flags.String("status", statusOnline, fmt.Sprintf(
+		"your status [options: %s]",
+		strings.Join(statuses, ", "),
+	))
+	emojiValue := flags.String("emoji", "", "your status emoji or its shortcode")
+	messageValue := flags.String("message", "", "your status message")
+	timeoutKey := flags.String("timeout", calendar.PresetNever, "timeout after which to delete your status, relative to --at (e.g. 1h or 13:00)")
+	tz := flags.String("tz", "", "time zone to resolve times in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	return func(args []string) error {
+		if *at == "" {
+			return usageError("--at is required")
+		}
+		if err := checkStatus(*statusValue); err != nil {
+			return err
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		atValue, err := cal.ParseTime(*at, cal.Now())
+		if err != nil {
+			return err
+		}
+
+		timeoutValue, err := cal.ParseTimeout(*timeoutKey, atValue)
+		if err != nil {
+			return err
+		}
+
+		store, err := schedule.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		entry, err := store.Add(atValue, ocs.UserStatus{
+			User:    auth.User,
+			Status:  *statusValue,
+			Icon:    emoji.Resolve(*emojiValue),
+			Message: *messageValue,
+			ClearAt: timeoutValue,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to save schedule: %s", err)
+		}
+
+		fmt.Printf("Scheduled %s for %s (id %s)\n", describeStatus(cal, entry.Status), cal.Format(entry.At), entry.Id)
+		if client, err := daemon.Dial(); err == nil {
+			client.Close()
+		} else {
+			fmt.Println("The daemon is not running, so the status is changed the next time you run nsc")
+		}
+
+		return nil
+	}
+}
+
+func setupScheduleList(flags *flag.FlagSet) func(args []string) error {
+	tz := flags.String("tz", "", "time zone to print times in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	return func(args []string) error {
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
+
+		store, err := schedule.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		entries, err := store.List()
+		if err != nil {
+			return fmt.Errorf("Failed to load schedules: %s", err)
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No scheduled status changes")
+			return nil
+		}
+
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s\t%s\n", entry.Id, cal.Format(entry.At), describeStatus(cal, entry.Status))
+		}
+		return nil
+	}
+}
+
+func setupScheduleCancel(flags *flag.FlagSet) func(args []string) error {
+	return func(positional []string) error {
+		if len(positional) != 1 {
+			return usageError("expected the id of a scheduled change")
+		}
+
+		store, err := schedule.DefaultStore()
+		if err != nil {
+			return err
+		}
+
+		return store.Cancel(positional[0])
+	}
+}
+
+func describeStatus(cal calendar.Calendar, status ocs.UserStatus) string {
+	description := status.Status
+	if status.Icon != "" {
+		description += " " + status.Icon
+	}
+	if status.Message != "" {
+		description += " " + status.Message
+	}
+	if status.ClearAt > 0 {
+		description += " until " + cal.Format(status.ClearAt)
+	}
+
+	return description
+}
+
+// newScheduleRunner returns a runner that applies due schedules with apply.
+func newScheduleRunner(cfg config.Config, apply func(ocs.UserStatus) error) (*schedule.Runner, error) {
+	catchUp, err := schedule.ParseCatchUp(cfg.ScheduleCatchUp)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := schedule.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return &schedule.Runner{
+		Store:   store,
+		CatchUp: catchUp,
+		Grace:   schedule.DefaultGrace,
+		Apply:   apply,
+	}, nil
+}
+
+// RunDueSchedules applies schedules that became due while the daemon was not
+// running. The daemon applies them itself otherwise.
+func RunDueSchedules() error {
+	if client, err := daemon.Dial(); err == nil {
+		client.Close()
+		return nil
+	}
+
+	auth, err := ocs.LoadAuth()
+	if err != nil {
+		// Nothing can be applied before logging in.
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("Failed to load config: %s", err)
+	}
+
+	runner, err := newScheduleRunner(cfg, func(status ocs.UserStatus) error {
+		return updateStatus(auth, status.Status, status.Message, status.Icon, status.ClearAt, false)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := runner.Tick(time.Now()); err != nil {
+		return fmt.Errorf("Failed to apply scheduled status: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package command
+
+import (
+	"errors"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/cache"
+	"github.com/st3iny/nextcloud-status-command/internal/daemon"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+// The helpers below talk to the daemon if it is running so its cache stays
+// up to date, and fall back to calling the server directly otherwise. Either
+// way, the local status cache is updated on success and invalidated on
+// errors. Changes are recorded in the history by the daemon or by the
+// helpers if it is not running.
+
+func getStatus(auth ocs.Auth) (*ocs.UserStatus, error) {
+	status, err := fetchStatus(auth)
+	if err != nil {
+		invalidateCachedStatus()
+		return nil, err
+	}
+
+	saveCachedStatus(status)
+	return status, nil
+}
+
+func fetchStatus(auth ocs.Auth) (*ocs.UserStatus, error) {
+	client, err := daemon.Dial()
+	if err != nil {
+		return ocs.GetStatus(auth)
+	}
+	defer client.Close()
+
+	return client.Get()
+}
+
+// updateStatus sets the given status. If restore is set, the daemon restores
+// the previous status once the timeout expires.
+func updateStatus(auth ocs.Auth, status, message, emoji string, timeout int64, restore bool) error {
+	userStatus := ocs.UserStatus{
+		User:    auth.User,
+		Status:  status,
+		Icon:    emoji,
+		Message: message,
+		ClearAt: timeout,
+	}
+
+	client, err := daemon.Dial()
+	if err != nil {
+		if restore {
+			return errors.New("Restoring your previous status requires a running daemon")
+		}
+
+		err = ocs.ApplyStatus(auth, userStatus)
+		if err == nil {
+			recordHistory(userStatus)
+		}
+	} else {
+		defer client.Close()
+		err = client.Set(daemon.SetParams{Status: userStatus, Restore: restore})
+	}
+	if err != nil {
+		invalidateCachedStatus()
+		return err
+	}
+
+	saveCachedStatus(&userStatus)
+	return nil
+}
+
+func clearStatus(auth ocs.Auth) error {
+	direct := false
+	client, err := daemon.Dial()
+	if err != nil {
+		direct = true
+		err = ocs.ClearStatusMessage(auth)
+	} else {
+		defer client.Close()
+		err = client.Clear()
+	}
+	if err != nil {
+		invalidateCachedStatus()
+		return err
+	}
+
+	// Only the message is cleared, so the cached status stays valid without
+	// it. Without a cached status the new one is unknown.
+	cleared := ocs.UserStatus{User: auth.User}
+	if entry := loadCachedStatus(); entry != nil && entry.Status != nil {
+		cleared.Status = entry.Status.Status
+		saveCachedStatus(&cleared)
+	} else {
+		invalidateCachedStatus()
+	}
+
+	if direct {
+		recordHistory(cleared)
+	}
+	return nil
+}
+
+// restoreStatus sets the previous status again unless the current status no
+// longer matches the one that was set, e.g. because it was changed in the
+// meantime. It reports whether the status was restored.
+func restoreStatus(auth ocs.Auth, set ocs.UserStatus, previous *ocs.UserStatus) (bool, error) {
+	current, err := getStatus(auth)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now().Unix()
+	if current == nil || !ocs.SameStatus(*current, set, now) {
+		return false, nil
+	}
+
+	status := ocs.UserStatus{Status: statusOnline}
+	if previous != nil {
+		status = *previous
+	}
+	if status.ClearAt > 0 && status.ClearAt <= now {
+		status.Icon = ""
+		status.Message = ""
+		status.ClearAt = 0
+	}
+
+	return true, updateStatus(auth, status.Status, status.Message, status.Icon, status.ClearAt, false)
+}
+
+// cachedStatus returns the cached status if it was fetched within maxAge.
+// Otherwise it is fetched and cached again.
+func cachedStatus(auth ocs.Auth, maxAge time.Duration) (*ocs.UserStatus, error) {
+	if entry := loadCachedStatus(); entry != nil && time.Since(entry.FetchedAt) <= maxAge {
+		return entry.Status, nil
+	}
+
+	return getStatus(auth)
+}
+
+// The cache is best effort, so errors of the helpers below are ignored.
+
+// loadCachedStatus returns the cached status regardless of its age or nil if
+// there is none.
+func loadCachedStatus() *cache.Entry {
+	store, err := cache.DefaultStore()
+	if err != nil {
+		return nil
+	}
+
+	entry, err := store.Load()
+	if err != nil {
+		return nil
+	}
+	return entry
+}
+
+func saveCachedStatus(status *ocs.UserStatus) {
+	if store, err := cache.DefaultStore(); err == nil {
+		store.Save(status, time.Now())
+	}
+}
+
+// recordHistory appends a status change made through nsc to the history. Like
+// the cache, the history is best effort.
+func recordHistory(status ocs.UserStatus) {
+	if log, err := history.DefaultLog(); err == nil {
+		log.Append(history.Entry{At: time.Now(), Source: history.SourceNsc, Status: status})
+	}
+}
+
+func invalidateCachedStatus() {
+	if store, err := cache.DefaultStore(); err == nil {
+		store.Invalidate()
+	}
+}
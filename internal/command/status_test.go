@@ -0,0 +1,67 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusCache(t *testing.T) {
+	t.Setenv("NSC_PROFILE", "")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	xdg.Reload()
+	defer xdg.Reload()
+
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"ocs": {"data": {"status": "dnd", "message": "In a meeting", "icon": "📅", "clearAt": null}}}`))
+	}))
+	defer server.Close()
+	auth := ocs.Auth{ServerBaseUrl: server.URL, User: "alice"}
+
+	_, err := getStatus(auth)
+	require.NoError(t, err)
+	entry := loadCachedStatus()
+	require.NotNil(t, entry)
+	assert.Equal(t, &ocs.UserStatus{User: "alice", Status: statusDnd, Icon: "📅", Message: "In a meeting"}, entry.Status)
+
+	// Clearing keeps the status without its message.
+	require.NoError(t, clearStatus(auth))
+	entry = loadCachedStatus()
+	require.NotNil(t, entry)
+	assert.Equal(t, &ocs.UserStatus{User: "alice", Status: statusDnd}, entry.Status)
+
+	// Errors leave the status unknown.
+	failing = true
+	_, err = getStatus(auth)
+	require.Error(t, err)
+	assert.Nil(t, loadCachedStatus())
+}
+
+func TestPrefillValues(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	status, icon, message, timeout := prefillValues(nil, now)
+	assert.Equal([]any{statusOnline, "", "", int64(0)}, []any{status, icon, message, timeout})
+
+	clearAt := now.Add(time.Hour).Unix()
+	status, icon, message, timeout = prefillValues(&ocs.UserStatus{Status: statusDnd, Icon: "📅", Message: "In a meeting", ClearAt: clearAt}, now)
+	assert.Equal([]any{statusDnd, "📅", "In a meeting", clearAt}, []any{status, icon, message, timeout})
+
+	// Expired messages of a stale cached status are not prefilled.
+	status, icon, message, timeout = prefillValues(&ocs.UserStatus{Status: statusDnd, Icon: "📅", Message: "In a meeting", ClearAt: now.Add(-time.Minute).Unix()}, now)
+	assert.Equal([]any{statusDnd, "", "", int64(0)}, []any{status, icon, message, timeout})
+}
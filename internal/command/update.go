@@ -4,15 +4,17 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
-	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/huh/spinner"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
 	"github.com/st3iny/nextcloud-status-command/internal/emoji"
 	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/preset"
 )
 
 const (
@@ -20,16 +22,20 @@ const (
 	statusAway      = "away"
 	statusDnd       = "dnd"
 	statusInvisible = "invisible"
-
-	timeoutNever     = "never"
-	timeout30Minutes = "30 minutes"
-	timeout1Hour     = "1 hour"
-	timeout4Hours    = "4 hours"
-	timeoutToday     = "today"
-	timeoutThisWeek  = "this week"
 )
 
-func RunUpdate() error {
+// statuses are the statuses that can be set.
+var statuses = ocs.Statuses
+
+// checkStatus rejects statuses that the server does not accept.
+func checkStatus(status string) error {
+	if !slices.Contains(statuses, status) {
+		return usageError("invalid status %q, expected one of %s", status, strings.Join(statuses, ", "))
+	}
+	return nil
+}
+
+func setupUpdate(flags *flag.FlagSet) func(args []string) error {
 	statusOptions := []string{
 		statusOnline,
 		statusAway,
@@ -37,104 +43,214 @@ func RunUpdate() error {
 		statusInvisible,
 	}
 	timeoutOptions := []string{
-		timeoutNever,
-		timeout30Minutes,
-		timeout1Hour,
-		timeout4Hours,
-		timeoutToday,
-		timeoutThisWeek,
+		calendar.PresetNever,
+		calendar.Preset30Minutes,
+		calendar.Preset1Hour,
+		calendar.Preset4Hours,
+		calendar.PresetEndOfWorkday,
+		calendar.PresetNextWorkday,
+		calendar.PresetToday,
+		calendar.PresetThisWeek,
 	}
 
 	defaultStatus := statusOnline
 	defaultEmoji := ""
 	defaultMessage := ""
-	defaultTimeoutKey := timeoutNever
+	defaultTimeoutKey := calendar.PresetNever
 
-	statusValue := flag.String("status", defaultStatus, fmt.Sprintf(
+	statusValue := flags.String("status", defaultStatus, fmt.Sprintf(
 		"your status [options: %s]",
 		strings.Join(statusOptions, ", "),
 	))
-	emojiValue := flag.String("emoji", defaultEmoji, "your status emoji")
-	messageValue := flag.String("message", defaultMessage, "your status message")
-	timeoutKey := flag.String("timeout", defaultTimeoutKey, fmt.Sprintf(
-		"timeout after which to delete your status [options: %s]",
+	emojiValue := flags.String("emoji", defaultEmoji, "your status emoji or its shortcode (e.g. :hot_beverage:)")
+	messageValue := flags.String("message", defaultMessage, "your status message")
+	messageId := flags.String("message-id", "", "id of a predefined status message to use as emoji and message (e.g. meeting)")
+	timeoutKey := flags.String("timeout", defaultTimeoutKey, fmt.Sprintf(
+		"timeout after which to delete your status, e.g. 90m, 17:30, until 9am tomorrow, friday or 2026-10-20T12:00 [presets: %s]",
 		strings.Join(timeoutOptions, ", "),
 	))
-	submit := flag.Bool("submit", false, "skip the form and submit your status directly")
-	empty := flag.Bool("empty", false, "do not prefill all fields with values from your current status")
-	flag.Parse()
+	submit := flags.Bool("submit", false, "skip the form and submit your status directly")
+	empty := flags.Bool("empty", false, "do not prefill all fields with values from your current status")
+	tz := flags.String("tz", "", "time zone to resolve the timeout in (e.g. Europe/Berlin), defaults to the configured or local time zone")
+	restore := flags.Bool("restore", false, "restore your previous status once the timeout expires (requires a running daemon)")
+	queueEnabled := addQueueFlag(flags)
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usageError("unknown command %q", args[0])
+		}
 
-	auth, err := ocs.LoadAuth()
-	if err != nil {
-		return missingAuthError()
-	}
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
 
-	var timeoutValue int64
-	if *empty || *statusValue != defaultStatus || *emojiValue != defaultEmoji || *messageValue != defaultMessage || *timeoutKey != defaultTimeoutKey {
-		timeoutValue = timeoutKeyToValue(*timeoutKey)
-	} else {
-		statusChannel := make(chan *ocs.UserStatus, 1)
-		errorChannel := make(chan error, 1)
+		cal, err := calendar.Load(*tz)
+		if err != nil {
+			return err
+		}
 
-		spinner.New().
-			Title("Fetching your current status ...").
-			Action(func() {
-				status, err := ocs.GetStatus(auth)
-				if err != nil {
-					errorChannel <- err
-					return
-				}
+		*emojiValue = emoji.Resolve(*emojiValue)
+		if *messageId != "" {
+			predefined, err := getPredefinedStatus(auth, *messageId)
+			if err != nil {
+				return err
+			}
+			*emojiValue = predefined.Icon
+			*messageValue = predefined.Message
+		}
 
-				statusChannel <- status
-			}).
-			Run()
+		var timeoutValue int64
+		prefill := func(status *ocs.UserStatus) {
+			*statusValue, *emojiValue, *messageValue, timeoutValue = prefillValues(status, time.Now())
+		}
 
-		select {
-		case err := <-errorChannel:
-			return fmt.Errorf("Failed to fetch current status: %s", err)
-		case status := <-statusChannel:
-			*statusValue = status.Status
-			*emojiValue = status.Icon
-			*messageValue = status.Message
-			timeoutValue = status.ClearAt
+		// A cached status prefills the form instantly and is refreshed while
+		// the form is open.
+		refresh := false
+		if *empty || *statusValue != defaultStatus || *emojiValue != defaultEmoji || *messageValue != defaultMessage || *timeoutKey != defaultTimeoutKey {
+			timeoutValue, err = cal.ParseTimeout(*timeoutKey, cal.Now())
+			if err != nil {
+				return err
+			}
+		} else if entry := loadCachedStatus(); entry != nil && !*submit {
+			prefill(entry.Status)
+			refresh = true
+		} else {
+			statusChannel := make(chan *ocs.UserStatus, 1)
+			errorChannel := make(chan error, 1)
+
+			spinner.New().
+				Title("Fetching your current status ...").
+				Action(func() {
+					status, err := getStatus(auth)
+					if err != nil {
+						errorChannel <- err
+						return
+					}
+
+					statusChannel <- status
+				}).
+				Run()
+
+			select {
+			case err := <-errorChannel:
+				return fmt.Errorf("Failed to fetch current status: %s", err)
+			case status := <-statusChannel:
+				prefill(status)
+			}
 		}
-	}
 
-	if !*submit {
-		model := newUpdateModel(statusValue, emojiValue, messageValue, &timeoutValue)
-		p := tea.NewProgram(model)
-		m, err := p.Run()
-		if err != nil {
-			return fmt.Errorf("Failed to render form: %s", err)
+		if !*submit {
+			model := newUpdateModel(cal, statusValue, emojiValue, messageValue, &timeoutValue, loadQuickPicks())
+			p := tea.NewProgram(model)
+			if refresh {
+				go func() {
+					if status, err := getStatus(auth); err == nil {
+						p.Send(statusRefreshedMsg{status: status})
+					}
+				}()
+			}
+			m, err := p.Run()
+			if err != nil {
+				return fmt.Errorf("Failed to render form: %s", err)
+			}
+
+			model = m.(updateModel)
+			if model.form.State != huh.StateCompleted {
+				return nil
+			}
+
+			status, err := model.status()
+			if err != nil {
+				return err
+			}
+			*statusValue, *emojiValue, *messageValue, timeoutValue = status.Status, status.Icon, status.Message, status.ClearAt
 		}
 
-		model = m.(updateModel)
-		if model.form.State != huh.StateCompleted {
-			return nil
+		if timeoutValue > 0 {
+			fmt.Printf("Your status will be deleted at %s\n", cal.Format(timeoutValue))
 		}
 
-		*statusValue = model.form.GetString("status")
-		*messageValue = model.form.GetString("message")
-		*emojiValue = model.form.GetString("emoji")
-		timeoutValue = model.form.Get("timeout").(int64)
-	}
+		errChan := make(chan error, 1)
+		err = spinner.New().
+			Title("Updating your status ...").
+			Action(func() {
+				errChan <- updateStatus(auth, *statusValue, *messageValue, *emojiValue, timeoutValue, *restore)
+			}).
+			Run()
+		if err != nil {
+			return fmt.Errorf("Failed to render spinner: %s", err)
+		}
 
-	return spinner.New().
-		Title("Updating your status ...").
-		Action(func() {
-			updateStatus(auth, *statusValue, *messageValue, *emojiValue, timeoutValue)
-		}).
-		Run()
+		err = <-errChan
+		if err != nil && queueEnabled() {
+			return queueChange(err, false, ocs.UserStatus{
+				User:    auth.User,
+				Status:  *statusValue,
+				Icon:    *emojiValue,
+				Message: *messageValue,
+				ClearAt: timeoutValue,
+			})
+		}
+		return err
+	}
 }
 
+// timeoutCustom is the value of the timeout option that asks for a custom
+// timeout expression.
+const timeoutCustom int64 = -1
+
 type updateModel struct {
-	form *huh.Form
+	form          *huh.Form
+	calendar      calendar.Calendar
+	customTimeout *string
+	// presetValue is the name of the chosen preset, empty for a custom
+	// status.
+	presetValue *string
+
+	statusValue  *string
+	emojiValue   *string
+	messageValue *string
+	timeoutValue *int64
+	picks        quickPicks
+
+	// edited is set once a key was pressed, so a refreshed status no longer
+	// replaces the prefilled values.
+	edited bool
+	size   *tea.WindowSizeMsg
+}
+
+// statusRefreshedMsg carries the current status fetched while the form is
+// prefilled with the cached one.
+type statusRefreshedMsg struct {
+	status *ocs.UserStatus
+}
+
+// prefillValues returns the form values of the given status. Messages past
+// their timeout have been cleared by the server already.
+func prefillValues(status *ocs.UserStatus, now time.Time) (string, string, string, int64) {
+	if status == nil {
+		return statusOnline, "", "", 0
+	}
+	if status.ClearAt > 0 && status.ClearAt <= now.Unix() {
+		return status.Status, "", "", 0
+	}
+
+	return status.Status, status.Icon, status.Message, status.ClearAt
 }
 
-func newUpdateModel(statusValue, emojiValue, messageValue *string, timeoutValue *int64) updateModel {
+func newUpdateModel(cal calendar.Calendar, statusValue, emojiValue, messageValue *string, timeoutValue *int64, picks quickPicks) updateModel {
+	// Recently used emojis come first.
 	emojiOptions := []huh.Option[string]{huh.NewOption("none", "")}
+	for _, e := range picks.emojis {
+		label := e + " (recent)"
+		if found, ok := emoji.Find(e); ok {
+			label = fmt.Sprintf("%s %s (recent)", e, found.Description)
+		}
+		emojiOptions = append(emojiOptions, huh.NewOption(label, e))
+	}
 	for _, e := range emoji.Emojis {
-		if len(e.Emoji) > 4 {
+		if len(e.Emoji) > 4 || slices.Contains(picks.emojis, e.Emoji) {
 			continue
 		}
 
@@ -142,8 +258,39 @@ func newUpdateModel(statusValue, emojiValue, messageValue *string, timeoutValue
 		emojiOptions = append(emojiOptions, option)
 	}
 
+	// Saved presets come first and skip the other fields.
+	presetValue := new(string)
+	presetOptions := []huh.Option[string]{}
+	for _, p := range picks.presets {
+		description := describePreset(p)
+		if status, err := p.Resolve(cal, cal.Now()); err == nil {
+			description = describeStatus(cal, status)
+		}
+		presetOptions = append(presetOptions, huh.NewOption(fmt.Sprintf("%s: %s", p.Name, description), p.Name))
+	}
+	presetOptions = append(presetOptions, huh.NewOption("custom status …", ""))
+
+	customTimeout := new(string)
+	timeoutPresetOptions := timeoutOptions(cal, timeoutValue)
 	return updateModel{
+		calendar:      cal,
+		customTimeout: customTimeout,
+		presetValue:   presetValue,
+		statusValue:   statusValue,
+		emojiValue:    emojiValue,
+		messageValue:  messageValue,
+		timeoutValue:  timeoutValue,
+		picks:         picks,
 		form: huh.NewForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Key("preset").
+					Options(presetOptions...).
+					Title("Choose a preset").
+					Value(presetValue),
+			).WithHideFunc(func() bool {
+				return len(picks.presets) == 0
+			}),
 			huh.NewGroup(
 				huh.NewSelect[string]().
 					Key("status").
@@ -161,22 +308,82 @@ func newUpdateModel(statusValue, emojiValue, messageValue *string, timeoutValue
 					Height(10).
 					Title("Choose an emoji (type / to search)").
 					Value(emojiValue),
-				huh.NewText().
+				huh.NewInput().
 					Key("message").
-					Lines(1).
 					Placeholder("Status message ...").
+					Suggestions(picks.messages).
 					Title("Type a status message").
 					Value(messageValue),
 				huh.NewSelect[int64]().
 					Key("timeout").
-					Options(timeoutOptions(timeoutValue)...).
+					Options(append(slices.Clip(timeoutPresetOptions), customTimeoutOption(cal, ""))...).
+					Height(len(timeoutPresetOptions)+2).
+					OptionsFunc(func() []huh.Option[int64] {
+						return append(slices.Clip(timeoutPresetOptions), customTimeoutOption(cal, *customTimeout))
+					}, customTimeout).
 					Title("Delete status after").
 					Value(timeoutValue),
-			),
+			).WithHideFunc(func() bool {
+				return *presetValue != ""
+			}),
+			huh.NewGroup(
+				huh.NewInput().
+					Key("customTimeout").
+					Placeholder("90m, 15:45, until 9am tomorrow, friday, 2026-10-20T12:00 ...").
+					Title("Type when to delete your status").
+					DescriptionFunc(func() string {
+						timeout, err := cal.ParseTimeout(*customTimeout, cal.Now())
+						if err != nil || timeout == 0 {
+							return "Your status will not be deleted"
+						}
+						return fmt.Sprintf("Your status will be deleted at %s", cal.Format(timeout))
+					}, customTimeout).
+					Validate(func(expr string) error {
+						_, err := cal.ParseTimeout(expr, cal.Now())
+						return err
+					}).
+					Value(customTimeout),
+			).WithHideFunc(func() bool {
+				return *presetValue != "" || *timeoutValue != timeoutCustom
+			}),
 		),
 	}
 }
 
+func customTimeoutOption(cal calendar.Calendar, expr string) huh.Option[int64] {
+	timeout, err := cal.ParseTimeout(expr, cal.Now())
+	if expr == "" || err != nil {
+		return huh.NewOption("custom …", timeoutCustom)
+	}
+
+	return huh.NewOption(fmt.Sprintf("custom … (%s)", cal.Format(timeout)), timeoutCustom)
+}
+
+// status returns the status chosen in the completed form. A chosen preset is
+// resolved now.
+func (m updateModel) status() (ocs.UserStatus, error) {
+	if *m.presetValue != "" {
+		i := slices.IndexFunc(m.picks.presets, func(p preset.Preset) bool {
+			return p.Name == *m.presetValue
+		})
+		return m.picks.presets[i].Resolve(m.calendar, m.calendar.Now())
+	}
+
+	status := ocs.UserStatus{
+		Status:  *m.statusValue,
+		Icon:    *m.emojiValue,
+		Message: *m.messageValue,
+		ClearAt: *m.timeoutValue,
+	}
+	if status.ClearAt != timeoutCustom {
+		return status, nil
+	}
+
+	var err error
+	status.ClearAt, err = m.calendar.ParseTimeout(*m.customTimeout, m.calendar.Now())
+	return status, err
+}
+
 func (m updateModel) Init() tea.Cmd {
 	return m.form.Init()
 }
@@ -188,6 +395,14 @@ func (m updateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c", "q", "esc":
 			return m, tea.Quit
 		}
+		m.edited = true
+	case tea.WindowSizeMsg:
+		m.size = &msg
+	case statusRefreshedMsg:
+		if m.edited {
+			return m, nil
+		}
+		return m.refreshed(msg.status)
 	}
 
 	var cmds []tea.Cmd
@@ -205,39 +420,26 @@ func (m updateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-func (m updateModel) View() string {
-	return m.form.View()
-}
-
-func updateStatus(auth ocs.Auth, status, message, emoji string, timeout int64) {
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	go func() {
-		err := ocs.UpdateStatus(auth, ocs.Status{
-			StatusType: status,
-		})
-		if err != nil {
-			fmt.Println(err)
-		}
-
-		wg.Done()
-	}()
-
-	go func() {
-		err := ocs.UpdateStatusMessage(auth, ocs.StatusMessage{
-			ClearAt:    timeout,
-			Message:    message,
-			StatusIcon: emoji,
-		})
-		if err != nil {
-			fmt.Println(err)
+// refreshed rebuilds the form with the values of the refreshed status.
+func (m updateModel) refreshed(status *ocs.UserStatus) (tea.Model, tea.Cmd) {
+	*m.statusValue, *m.emojiValue, *m.messageValue, *m.timeoutValue = prefillValues(status, time.Now())
+
+	refreshed := newUpdateModel(m.calendar, m.statusValue, m.emojiValue, m.messageValue, m.timeoutValue, m.picks)
+	refreshed.size = m.size
+	cmds := []tea.Cmd{refreshed.form.Init()}
+	if m.size != nil {
+		form, cmd := refreshed.form.Update(*m.size)
+		if f, ok := form.(*huh.Form); ok {
+			refreshed.form = f
 		}
+		cmds = append(cmds, cmd)
+	}
 
-		wg.Done()
-	}()
+	return refreshed, tea.Batch(cmds...)
+}
 
-	wg.Wait()
+func (m updateModel) View() string {
+	return m.form.View()
 }
 
 func missingAuthError() error {
@@ -248,33 +450,11 @@ func missingAuthError() error {
 	)
 }
 
-func daysFromStartOfDayUntilEndOfSunday(date time.Time) int {
-	var daysUntilEndOfSunday int
-	weekday := int(date.Weekday())
-	if weekday == 0 /* Sunday */ {
-		daysUntilEndOfSunday = 1
-	} else /* Not Sunday */ {
-		daysUntilEndOfSunday = 7 - weekday + 1
-	}
-	return daysUntilEndOfSunday
-}
-
-func timeoutOptions(timeoutValue *int64) []huh.Option[int64] {
-	now := time.Now()
-	nowUnix := now.Unix()
-	startOfTodayUnix := time.
-		Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).
-		Unix()
-	daysUntilSunday := int64(daysFromStartOfDayUntilEndOfSunday(now))
-	options := []huh.Option[int64]{
-		huh.NewOption(timeoutNever, int64(0)),
-		huh.NewOption(timeout30Minutes, nowUnix+1800),
-		huh.NewOption(timeout1Hour, nowUnix+3600),
-		huh.NewOption(timeout4Hours, nowUnix+4*3600),
-		huh.NewOption(timeoutToday, startOfTodayUnix+24*3600),
-		huh.NewOption(timeoutThisWeek, startOfTodayUnix+daysUntilSunday*24*3600),
+func timeoutOptions(cal calendar.Calendar, timeoutValue *int64) []huh.Option[int64] {
+	var options []huh.Option[int64]
+	for _, preset := range cal.Presets(cal.Now()) {
+		options = append(options, huh.NewOption(preset.Label, preset.Timeout))
 	}
-
 	if timeoutValue == nil {
 		return options
 	}
@@ -289,7 +469,7 @@ func timeoutOptions(timeoutValue *int64) []huh.Option[int64] {
 
 	if needsCustomOption {
 		options = append(options, huh.NewOption(
-			fmt.Sprintf("custom (%s)", time.Unix(*timeoutValue, 0)),
+			fmt.Sprintf("custom (%s)", cal.Format(*timeoutValue)),
 			*timeoutValue,
 		))
 	}
@@ -297,12 +477,16 @@ func timeoutOptions(timeoutValue *int64) []huh.Option[int64] {
 	return options
 }
 
-func timeoutKeyToValue(timeoutKey string) int64 {
-	for _, option := range timeoutOptions(nil) {
-		if option.Key == timeoutKey {
-			return option.Value
-		}
+func getPredefinedStatus(auth ocs.Auth, id string) (ocs.PredefinedStatus, error) {
+	statuses, err := ocs.GetPredefinedStatuses(auth)
+	if err != nil {
+		return ocs.PredefinedStatus{}, err
 	}
 
-	return 0
+	for _, status := range statuses {
+		if status.Id == id {
+			return status, nil
+		}
+	}
+	return ocs.PredefinedStatus{}, fmt.Errorf("Unknown predefined status message %q", id)
 }
@@ -4,43 +4,42 @@ import (
 	"testing"
 	"time"
 
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/preset"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestDaysFromStartOfDayUntilEndOfSunday(t *testing.T) {
-	assert := assert.New(t)
-
-	loc, err := time.LoadLocation("Europe/Berlin")
-	if err != nil {
-		panic(err)
-	}
-
-	sunday := time.Date(2024, 6, 2, 18, 7, 0, 0, loc)
-	monday := time.Date(2024, 6, 3, 18, 7, 0, 0, loc)
-	tuesday := time.Date(2024, 6, 4, 18, 7, 0, 0, loc)
-	wednesday := time.Date(2024, 6, 5, 18, 7, 0, 0, loc)
-	thursday := time.Date(2024, 6, 6, 18, 7, 0, 0, loc)
-	friday := time.Date(2024, 6, 7, 18, 7, 0, 0, loc)
-	saturday := time.Date(2024, 6, 8, 18, 7, 0, 0, loc)
-
-	assert.Equal(1, daysFromStartOfDayUntilEndOfSunday(sunday))
-	assert.Equal(7, daysFromStartOfDayUntilEndOfSunday(monday))
-	assert.Equal(6, daysFromStartOfDayUntilEndOfSunday(tuesday))
-	assert.Equal(5, daysFromStartOfDayUntilEndOfSunday(wednesday))
-	assert.Equal(4, daysFromStartOfDayUntilEndOfSunday(thursday))
-	assert.Equal(3, daysFromStartOfDayUntilEndOfSunday(friday))
-	assert.Equal(2, daysFromStartOfDayUntilEndOfSunday(saturday))
-}
-
 func TestTimeoutOptions(t *testing.T) {
 	assert := assert.New(t)
 
-	options := timeoutOptions(nil)
+	options := timeoutOptions(calendar.Default(), nil)
 	for _, option := range options {
 		assert.NotContains("custom", option)
 	}
 
 	timestamp := int64(1000)
-	options = timeoutOptions(&timestamp)
+	options = timeoutOptions(calendar.Default(), &timestamp)
 	assert.Contains(options[len(options)-1].Key, "custom")
 }
+
+func TestUpdateModelStatus(t *testing.T) {
+	assert := assert.New(t)
+	cal := calendar.Default()
+
+	statusValue, emojiValue, messageValue, timeoutValue := statusAway, "🍔", "Lunch", int64(0)
+	picks := quickPicks{presets: []preset.Preset{{Name: "focus", Status: statusDnd, Message: "Focus until {{.Until}}", Timeout: "1h"}}}
+	model := newUpdateModel(cal, &statusValue, &emojiValue, &messageValue, &timeoutValue, picks)
+
+	status, err := model.status()
+	assert.NoError(err)
+	assert.Equal(ocs.UserStatus{Status: statusAway, Icon: "🍔", Message: "Lunch"}, status)
+
+	// A chosen preset replaces the other fields.
+	*model.presetValue = "focus"
+	status, err = model.status()
+	assert.NoError(err)
+	assert.Equal(statusDnd, status.Status)
+	assert.Greater(status.ClearAt, time.Now().Unix())
+	assert.Regexp(`^Focus until (\w{3} )?\d\d:\d\d$`, status.Message)
+}
@@ -0,0 +1,175 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const statusOffline = "offline"
+
+// watchStatuses are the statuses of other users, which never appear as
+// invisible.
+var watchStatuses = []string{statusOnline, statusAway, statusDnd, statusOffline}
+
+func setupWatch(flags *flag.FlagSet) func(args []string) error {
+	until := flags.String("until", "", fmt.Sprintf(
+		"exit once the user has one of these comma separated statuses [options: %s]",
+		strings.Join(watchStatuses, ", "),
+	))
+	interval := flags.Duration("interval", 30*time.Second, "interval between polls of the user's status")
+	notify := flags.String("notify", "", "shell command to run on every status change, the change is passed as the last argument (e.g. notify-send)")
+	bell := flags.Bool("bell", false, "ring the terminal bell on every status change")
+	return func(positional []string) error {
+		if len(positional) != 1 {
+			return usageError("expected a user")
+		}
+
+		untilStatuses, err := parseUntil(*until)
+		if err != nil {
+			return err
+		}
+		if err := checkPositive("interval", *interval); err != nil {
+			return err
+		}
+
+		auth, err := ocs.LoadAuth()
+		if err != nil {
+			return missingAuthError()
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		w := watcher{
+			auth:     auth,
+			user:     positional[0],
+			interval: *interval,
+			bell:     *bell,
+			out:      os.Stdout,
+			now:      time.Now,
+			until:    untilStatuses,
+		}
+		if *notify != "" {
+			w.notify = shellCommand(*notify)
+		}
+
+		return w.run(ctx)
+	}
+}
+
+// shellCommand returns the command line to run command with sh, so it may
+// quote its arguments. Arguments appended to the command line are passed on
+// to the command.
+func shellCommand(command string) []string {
+	return []string{"sh", "-c", command + ` "$@"`, "sh"}
+}
+
+// parseUntil parses the comma separated statuses of --until.
+func parseUntil(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var until []string
+	for _, status := range strings.Split(value, ",") {
+		status = strings.TrimSpace(status)
+		if !slices.Contains(watchStatuses, status) {
+			return nil, usageError("invalid status %q, expected one of %s", status, strings.Join(watchStatuses, ", "))
+		}
+		until = append(until, status)
+	}
+
+	return until, nil
+}
+
+type watcher struct {
+	auth     ocs.Auth
+	user     string
+	until    []string
+	interval time.Duration
+	notify   []string
+	bell     bool
+	out      io.Writer
+	now      func() time.Time
+}
+
+// run polls the user's status until the target condition is reached or the
+// context is cancelled. Transient errors are reported and polling continues.
+func (w watcher) run(ctx context.Context) error {
+	var last *ocs.UserStatus
+	for {
+		status, err := ocs.GetUserStatus(w.auth, w.user)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fetch status of %s: %s\n", w.user, err)
+		} else {
+			if last == nil || *last != *status {
+				w.report(last, status)
+			}
+			last = status
+
+			if slices.Contains(w.until, status.Status) {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if len(w.until) == 0 {
+				return nil
+			}
+			return errors.New("Stopped watching before the target status was reached")
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+func (w watcher) report(old, status *ocs.UserStatus) {
+	description := status.Status
+	if status.Icon != "" {
+		description += " " + status.Icon
+	}
+	if status.Message != "" {
+		description += " " + status.Message
+	}
+
+	var change string
+	if old == nil {
+		change = fmt.Sprintf("%s is %s", status.User, description)
+	} else {
+		change = fmt.Sprintf("%s changed from %s to %s", status.User, old.Status, description)
+	}
+	fmt.Fprintf(w.out, "%s %s\n", w.now().Format(time.DateTime), change)
+
+	if old == nil && !slices.Contains(w.until, status.Status) {
+		return
+	}
+
+	if w.bell {
+		fmt.Fprint(w.out, "\a")
+	}
+
+	if len(w.notify) > 0 {
+		cmd := exec.Command(w.notify[0], append(w.notify[1:], change)...)
+		cmd.Env = append(os.Environ(),
+			"NSC_USER="+status.User,
+			"NSC_STATUS="+status.Status,
+			"NSC_ICON="+status.Icon,
+			"NSC_MESSAGE="+status.Message,
+		)
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to run notification command: %s\n", err)
+		}
+	}
+}
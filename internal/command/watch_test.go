@@ -0,0 +1,124 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStatusServer serves the given statuses of bob one after another and keeps
+// serving the last one. An empty status is served as a missing status.
+func newStatusServer(statuses ...string) *httptest.Server {
+	var polls atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := min(int(polls.Add(1))-1, len(statuses)-1)
+		if statuses[i] == "" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"ocs":{"meta":{"statuscode":404},"data":[]}}`)
+			return
+		}
+
+		fmt.Fprintf(w, `{"ocs":{"data":{"userId":"bob","status":"%s","message":null,"icon":null,"clearAt":null}}}`, statuses[i])
+	}))
+}
+
+func newTestWatcher(server *httptest.Server, out *bytes.Buffer) watcher {
+	return watcher{
+		auth:     ocs.Auth{ServerBaseUrl: server.URL, User: "alice"},
+		user:     "bob",
+		interval: time.Millisecond,
+		out:      out,
+		now: func() time.Time {
+			return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		},
+	}
+}
+
+func TestParseUntil(t *testing.T) {
+	until, err := parseUntil("")
+	assert.NoError(t, err)
+	assert.Empty(t, until)
+
+	until, err = parseUntil("online, away")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"online", "away"}, until)
+
+	for _, value := range []string{"onlin", "online,invisible", "away,"} {
+		_, err := parseUntil(value)
+		assert.IsType(t, UsageError{}, err, value)
+	}
+}
+
+func TestWatchUntilOnline(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newStatusServer("", "away", "away", "dnd", "online")
+	defer server.Close()
+
+	var out bytes.Buffer
+	w := newTestWatcher(server, &out)
+	w.until = []string{statusOnline}
+	w.bell = true
+
+	err := w.run(context.Background())
+	assert.NoError(err)
+	assert.Equal(
+		"2026-10-19 12:00:00 bob is offline\n"+
+			"2026-10-19 12:00:00 bob changed from offline to away\n\a"+
+			"2026-10-19 12:00:00 bob changed from away to dnd\n\a"+
+			"2026-10-19 12:00:00 bob changed from dnd to online\n\a",
+		out.String(),
+	)
+}
+
+func TestWatchRunsNotifyCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newStatusServer("away", "online")
+	defer server.Close()
+
+	notified := filepath.Join(t.TempDir(), "notified")
+	var out bytes.Buffer
+	w := newTestWatcher(server, &out)
+	w.until = []string{statusOnline}
+	w.notify = shellCommand(`printf "%s: %s" "$NSC_STATUS"`)
+	w.notify[2] += " > " + notified
+
+	err := w.run(context.Background())
+	assert.NoError(err)
+
+	content, err := os.ReadFile(notified)
+	assert.NoError(err)
+	assert.Equal("online: bob changed from away to online", string(content))
+}
+
+func TestWatchStopsOnCancel(t *testing.T) {
+	server := newStatusServer("away")
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+	w := newTestWatcher(server, &out)
+	w.until = []string{statusOnline}
+
+	assert.Error(t, w.run(ctx))
+	assert.Equal(t, "2026-10-19 12:00:00 bob is away\n", out.String())
+
+	// Without a target status, watching is stopped successfully.
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	w.until = nil
+	assert.NoError(t, w.run(ctx))
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/adrg/xdg"
+)
+
+type Config struct {
+	// TimeZone is the IANA name of the time zone used to resolve timeouts.
+	// It defaults to the local time zone.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// WeekStart is the name of the first day of the week. It defaults to
+	// Monday.
+	WeekStart string `json:"weekStart,omitempty"`
+
+	// DayEnd is the clock time at which a day ends (e.g. "06:00" for night
+	// shifts). It defaults to midnight.
+	DayEnd string `json:"dayEnd,omitempty"`
+
+	// WorkdayEnd is the clock time at which a workday ends if no working
+	// hours are configured. It defaults to 17:00.
+	WorkdayEnd string `json:"workdayEnd,omitempty"`
+
+	// WorkingHours maps weekdays or ranges of weekdays (e.g. "mon-thu") to
+	// working hours (e.g. "09:00-17:00"). It defaults to 09:00 until the
+	// workday end from Monday to Friday.
+	WorkingHours map[string]string `json:"workingHours,omitempty"`
+
+	// OffHoursStatus is the status the daemon sets when working hours end
+	// (e.g. "away" or "invisible"). It sets "online" again once they start.
+	// Nothing is changed if it is empty.
+	OffHoursStatus string `json:"offHoursStatus,omitempty"`
+
+	// ScheduleCatchUp decides what happens to scheduled status changes that
+	// were missed: "latest" applies only the latest one, "all" applies all
+	// of them in order and "skip" drops them. It defaults to "latest".
+	ScheduleCatchUp string `json:"scheduleCatchUp,omitempty"`
+
+	// OfflineQueue queues status changes that fail because the server is
+	// not reachable and applies them once it is again.
+	OfflineQueue bool `json:"offlineQueue,omitempty"`
+
+	// Rules are recurring status changes applied by the daemon.
+	Rules []Rule `json:"rules,omitempty"`
+
+	// Holidays are dates (e.g. "2026-12-24") without working hours on which
+	// no rules are applied.
+	Holidays []string `json:"holidays,omitempty"`
+
+	// Calendars are watched by the daemon, which sets a do not disturb
+	// status during their events.
+	Calendars []Calendar `json:"calendars,omitempty"`
+
+	// Focus holds the defaults of focus sessions.
+	Focus Focus `json:"focus,omitempty"`
+}
+
+type Focus struct {
+	// Duration is the length of a focus session (e.g. "25m").
+	Duration string `json:"duration,omitempty"`
+
+	// Cycles is the number of focus sessions with breaks in between.
+	Cycles int `json:"cycles,omitempty"`
+
+	Break     string `json:"break,omitempty"`
+	LongBreak string `json:"longBreak,omitempty"`
+
+	// LongBreakEvery is the number of focus sessions after which a long
+	// break is taken instead of a short one.
+	LongBreakEvery int `json:"longBreakEvery,omitempty"`
+
+	Message string `json:"message,omitempty"`
+}
+
+type Calendar struct {
+	Name string `json:"name,omitempty"`
+
+	// Source is the path or http(s) URL of a calendar in iCalendar format,
+	// "caldav" for all calendars on the Nextcloud server or "caldav:<name>"
+	// for a single one.
+	Source string `json:"source"`
+
+	// IncludeFree also considers events that are marked as free.
+	IncludeFree bool `json:"includeFree,omitempty"`
+
+	// IncludeAllDay also considers all-day events.
+	IncludeAllDay bool `json:"includeAllDay,omitempty"`
+
+	// Ignore is a regular expression matching the summaries of events to
+	// ignore.
+	Ignore string `json:"ignore,omitempty"`
+}
+
+type Rule struct {
+	Name string `json:"name"`
+
+	// When is a cron expression (e.g. "0 12 * * mon-fri") or a recurrence
+	// rule (e.g. "FREQ=WEEKLY;BYDAY=FR;BYHOUR=16").
+	When string `json:"when"`
+
+	Status  string `json:"status,omitempty"`
+	Icon    string `json:"icon,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Timeout is resolved relative to each occurrence (e.g. "45m" or
+	// "today").
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func Load() (Config, error) {
+	configPath, err := xdg.ConfigFile("nsc/config.json")
+	if err != nil {
+		return Config{}, err
+	}
+
+	configJson, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	} else if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	err = json.Unmarshal(configJson, &config)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const callTimeout = 30 * time.Second
+
+type Client struct {
+	conn   net.Conn
+	enc    *json.Encoder
+	dec    *json.Decoder
+	nextId int
+}
+
+// Dial connects to a running daemon. An error is returned if no daemon is
+// listening on the socket.
+func Dial() (*Client, error) {
+	socketPath, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return DialPath(socketPath)
+}
+
+func DialPath(socketPath string) (*Client, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn: conn,
+		enc:  json.NewEncoder(conn),
+		dec:  json.NewDecoder(conn),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) call(method string, params any, result any) error {
+	c.nextId++
+	req := Request{Id: c.nextId, Method: method}
+	if params != nil {
+		paramsJson, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = paramsJson
+	}
+
+	c.conn.SetDeadline(time.Now().Add(callTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	if err := c.enc.Encode(req); err != nil {
+		return err
+	}
+
+	var res Response
+	if err := c.dec.Decode(&res); err != nil {
+		return err
+	}
+
+	if res.Error != "" && res.Offline {
+		return &ocs.TransportError{Err: errors.New(res.Error)}
+	} else if res.Error != "" {
+		return errors.New(res.Error)
+	}
+
+	if result != nil && res.Result != nil {
+		return json.Unmarshal(res.Result, result)
+	}
+
+	return nil
+}
+
+// Get returns the status cached by the daemon or nil if the user has none.
+func (c *Client) Get() (*ocs.UserStatus, error) {
+	var status *ocs.UserStatus
+	err := c.call(MethodGet, nil, &status)
+	return status, err
+}
+
+func (c *Client) Set(params SetParams) error {
+	return c.call(MethodSet, params, nil)
+}
+
+func (c *Client) Clear() error {
+	return c.call(MethodClear, nil, nil)
+}
+
+// Subscribe calls fn with the current status and on every change until the
+// connection is closed.
+func (c *Client) Subscribe(fn func(*ocs.UserStatus)) error {
+	var status *ocs.UserStatus
+	if err := c.call(MethodSubscribe, nil, &status); err != nil {
+		return err
+	}
+	fn(status)
+
+	for {
+		var notification Response
+		if err := c.dec.Decode(&notification); err != nil {
+			return err
+		}
+
+		if notification.Method != notificationStatus {
+			continue
+		}
+
+		status = nil
+		if err := json.Unmarshal(notification.Result, &status); err != nil {
+			return err
+		}
+		fn(status)
+	}
+}
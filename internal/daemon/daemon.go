@@ -0,0 +1,641 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/cache"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/heartbeat"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/jsonfile"
+	"github.com/st3iny/nextcloud-status-command/internal/meeting"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/queue"
+	"github.com/st3iny/nextcloud-status-command/internal/rules"
+	"github.com/st3iny/nextcloud-status-command/internal/schedule"
+	"github.com/st3iny/nextcloud-status-command/internal/sdnotify"
+)
+
+type Daemon struct {
+	Auth       ocs.Auth
+	SocketPath string
+
+	// PollInterval is the interval in which the cached status is refreshed
+	// and pending restores are applied.
+	PollInterval time.Duration
+
+	// Heartbeat is optional and reports the user's activity while the
+	// daemon is running.
+	Heartbeat *heartbeat.Heartbeat
+
+	// Calendar is optional and sets OffHoursStatus when the working hours
+	// end and online again when they start.
+	Calendar       *calendar.Calendar
+	OffHoursStatus string
+
+	// Queue is optional and applies status changes queued while the server
+	// was not reachable.
+	Queue *queue.Runner
+
+	// Schedule is optional and applies scheduled status changes.
+	Schedule *schedule.Runner
+
+	// Rules is optional and applies recurring status changes.
+	Rules *rules.Runner
+
+	// Meetings is optional and sets a meeting status during the events of
+	// calendars.
+	Meetings *meeting.Watcher
+
+	// StatusCache is optional and receives every fetched status, so other
+	// processes can read it without a request to the server.
+	StatusCache *cache.Store
+
+	// History is optional and receives every status change made by the
+	// daemon. If RecordObserved is set, changes noticed while polling are
+	// recorded as well.
+	History        *history.Log
+	RecordObserved bool
+
+	// RestorePath is optional and keeps a pending restore in a file, so it
+	// survives restarts, e.g. between runs with --once.
+	RestorePath string
+
+	mu            sync.Mutex
+	status        *ocs.UserStatus
+	fetched       bool
+	restore       *restore
+	restoreLoaded bool
+	subscribers   map[chan *ocs.UserStatus]struct{}
+	working       *bool
+}
+
+type restore struct {
+	Status ocs.UserStatus `json:"status"`
+	At     time.Time      `json:"at"`
+
+	// Applied is the status that is replaced by the restore. The restore is
+	// dropped if the status was changed in the meantime.
+	Applied ocs.UserStatus `json:"applied"`
+}
+
+// loadRestore reads the pending restore from RestorePath once. It must be
+// called with mu held.
+func (d *Daemon) loadRestore() {
+	if d.restoreLoaded || d.RestorePath == "" {
+		return
+	}
+	d.restoreLoaded = true
+
+	var pending *restore
+	if err := jsonfile.Read(d.RestorePath, &pending); err != nil {
+		log.Printf("Failed to load pending restore: %s", err)
+		return
+	}
+	d.restore = pending
+}
+
+// setRestore replaces the pending restore and saves it to RestorePath. It
+// must be called with mu held.
+func (d *Daemon) setRestore(pending *restore) {
+	d.loadRestore()
+	d.restore = pending
+	if d.RestorePath == "" {
+		return
+	}
+
+	var err error
+	if pending == nil {
+		err = os.Remove(d.RestorePath)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	} else {
+		err = jsonfile.Write(d.RestorePath, pending)
+	}
+	if err != nil {
+		log.Printf("Failed to save pending restore: %s", err)
+	}
+}
+
+// Run listens on the control socket and keeps the cached status up to date
+// until the context is cancelled.
+func (d *Daemon) Run(ctx context.Context) error {
+	listener, err := d.listen()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(d.SocketPath)
+
+	d.subscribers = map[chan *ocs.UserStatus]struct{}{}
+	if err := d.refresh(); err != nil {
+		log.Printf("Failed to fetch current status: %s", err)
+	}
+
+	sdnotify.Ready()
+	defer sdnotify.Stopping()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		d.accept(ctx, listener)
+		wg.Done()
+	}()
+	go func() {
+		d.poll(ctx)
+		wg.Done()
+	}()
+
+	if d.Heartbeat != nil {
+		h := *d.Heartbeat
+		h.OnBeat = func(activity string, status *ocs.UserStatus, err error) {
+			if err != nil {
+				log.Printf("Failed to send heartbeat: %s", err)
+				return
+			}
+
+			if status != nil {
+				d.cache(status)
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			h.Run(ctx)
+			wg.Done()
+		}()
+	}
+
+	<-ctx.Done()
+	listener.Close()
+	wg.Wait()
+	return nil
+}
+
+func (d *Daemon) listen() (net.Listener, error) {
+	if conn, err := net.Dial("unix", d.SocketPath); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("Daemon is already running at %s", d.SocketPath)
+	}
+
+	// The socket of a daemon that did not shut down cleanly is left behind.
+	os.Remove(d.SocketPath)
+	listener, err := net.Listen("unix", d.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to listen on %s: %s", d.SocketPath, err)
+	}
+
+	return listener, nil
+}
+
+func (d *Daemon) accept(ctx context.Context, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to accept connection: %s", err)
+			}
+			return
+		}
+
+		go d.serve(ctx, conn)
+	}
+}
+
+// poll ticks in every poll interval. It also pings the systemd watchdog, so
+// the daemon is restarted if a tick hangs.
+func (d *Daemon) poll(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	var watchdog <-chan time.Time
+	if interval := sdnotify.WatchdogInterval(); interval > 0 {
+		watchdogTicker := time.NewTicker(interval / 2)
+		defer watchdogTicker.Stop()
+		watchdog = watchdogTicker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.Tick(now)
+		case <-watchdog:
+			sdnotify.Watchdog()
+		}
+	}
+}
+
+// Tick applies a pending restore, queued and scheduled status changes, rules
+// and meetings if they are due, refreshes the cached status and follows the
+// working hours.
+func (d *Daemon) Tick(now time.Time) {
+	d.mu.Lock()
+	d.loadRestore()
+	pending := d.restore
+	d.mu.Unlock()
+
+	if pending != nil && !now.Before(pending.At) {
+		d.applyRestore(*pending, now)
+	}
+
+	if d.Queue != nil {
+		if _, err := d.Queue.Flush(now); err != nil && !ocs.IsTransportError(err) {
+			log.Printf("Failed to apply queued status changes: %s", err)
+		}
+	}
+
+	if d.Schedule != nil {
+		if err := d.Schedule.Tick(now); err != nil {
+			log.Printf("Failed to apply scheduled status: %s", err)
+		}
+	}
+
+	if d.Rules != nil {
+		if err := d.Rules.Tick(now); err != nil {
+			log.Printf("Failed to apply rules: %s", err)
+		}
+	}
+
+	if d.Meetings != nil {
+		if err := d.Meetings.Tick(now); err != nil {
+			log.Printf("Failed to follow calendars: %s", err)
+		}
+	}
+
+	if err := d.refresh(); err != nil {
+		log.Printf("Failed to refresh status: %s", err)
+		return
+	}
+
+	if d.Calendar != nil && d.OffHoursStatus != "" {
+		if err := d.followWorkingHours(now); err != nil {
+			log.Printf("Failed to follow working hours: %s", err)
+		}
+	}
+}
+
+// followWorkingHours switches between online and the off hours status when
+// the working hours start or end. Statuses that were chosen manually, like
+// dnd during a late meeting, are left alone.
+func (d *Daemon) followWorkingHours(now time.Time) error {
+	working := d.Calendar.IsWorkingTime(now)
+
+	d.mu.Lock()
+	changed := d.working == nil || *d.working != working
+	d.working = &working
+	current := d.status
+	d.mu.Unlock()
+
+	if !changed || current == nil {
+		return nil
+	}
+
+	var from, to string
+	if working {
+		from, to = d.OffHoursStatus, heartbeat.StatusOnline
+	} else {
+		from, to = heartbeat.StatusOnline, d.OffHoursStatus
+	}
+	if current.Status != from {
+		return nil
+	}
+
+	if err := ocs.UpdateStatus(d.Auth, ocs.Status{StatusType: to}); err != nil {
+		return err
+	}
+
+	status := *current
+	status.Status = to
+	d.cache(&status)
+	d.record(status, history.SourceNsc)
+	return nil
+}
+
+func (d *Daemon) refresh() error {
+	status, err := ocs.GetStatus(d.Auth)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	previous, fetched := d.status, d.fetched
+	d.mu.Unlock()
+
+	d.cache(status)
+
+	observed := fetched && status != nil && (previous == nil || *previous != *status)
+	if d.RecordObserved && observed {
+		d.record(*status, history.SourceObserved)
+	}
+	return nil
+}
+
+func (d *Daemon) record(status ocs.UserStatus, source string) {
+	if d.History == nil {
+		return
+	}
+
+	entry := history.Entry{At: time.Now(), Source: source, Status: status}
+	if err := d.History.Append(entry); err != nil {
+		log.Printf("Failed to record status change: %s", err)
+	}
+}
+
+func (d *Daemon) cache(status *ocs.UserStatus) {
+	if d.StatusCache != nil {
+		if err := d.StatusCache.Save(status, time.Now()); err != nil {
+			log.Printf("Failed to cache status: %s", err)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	unchanged := d.fetched && (d.status == status || d.status != nil && status != nil && *d.status == *status)
+	d.status = status
+	d.fetched = true
+	if unchanged {
+		return
+	}
+
+	sdnotify.Status(describe(status))
+
+	for subscriber := range d.subscribers {
+		select {
+		case subscriber <- status:
+		default:
+			// Drop updates for subscribers that do not keep up.
+		}
+	}
+}
+
+func describe(status *ocs.UserStatus) string {
+	if status == nil {
+		return "No status"
+	}
+
+	description := status.Status
+	if status.Icon != "" {
+		description += " " + status.Icon
+	}
+	if status.Message != "" {
+		description += " " + status.Message
+	}
+
+	return description
+}
+
+func (d *Daemon) cached() (*ocs.UserStatus, error) {
+	d.mu.Lock()
+	status, fetched := d.status, d.fetched
+	d.mu.Unlock()
+
+	if fetched {
+		return status, nil
+	}
+
+	if err := d.refresh(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status, nil
+}
+
+// applyRestore sets the previous status again unless the status was changed
+// since it was replaced. The restore is kept if the server is unreachable, so
+// it is retried on the next tick.
+func (d *Daemon) applyRestore(pending restore, now time.Time) {
+	current, err := ocs.GetStatus(d.Auth)
+	if err == nil && (current == nil || !ocs.SameStatus(*current, pending.Applied, now.Unix())) {
+		log.Printf("Your status was changed and is left as is")
+		d.dropRestore(pending)
+		return
+	}
+
+	status := pending.Status
+	if status.ClearAt > 0 && status.ClearAt <= now.Unix() {
+		status.Icon = ""
+		status.Message = ""
+		status.ClearAt = 0
+	}
+	if err == nil {
+		err = ocs.ApplyStatus(d.Auth, status)
+	}
+
+	if ocs.IsTransportError(err) {
+		log.Printf("Failed to restore previous status, retrying: %s", err)
+		return
+	} else if err != nil {
+		log.Printf("Failed to restore previous status: %s", err)
+	} else {
+		d.cache(&status)
+		d.record(status, history.SourceNsc)
+	}
+	d.dropRestore(pending)
+}
+
+// dropRestore removes the pending restore unless it was replaced in the
+// meantime.
+func (d *Daemon) dropRestore(pending restore) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.restore != nil && *d.restore == pending {
+		d.setRestore(nil)
+	}
+}
+
+// Set applies the given status. If requested, the previous status is
+// restored once the new status is cleared.
+func (d *Daemon) Set(params SetParams) error {
+	var previous *ocs.UserStatus
+	if params.Restore && params.Status.ClearAt > 0 {
+		var err error
+		previous, err = d.cached()
+		if err != nil {
+			return err
+		}
+
+		if previous == nil {
+			previous = &ocs.UserStatus{User: d.Auth.User, Status: "online"}
+		}
+	}
+
+	if err := ocs.ApplyStatus(d.Auth, params.Status); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.loadRestore()
+	if previous != nil && d.restore != nil && d.restore.At.After(time.Now()) {
+		// The status is set again before the previous one was restored,
+		// e.g. by back-to-back meetings or another run with --once, so
+		// the status from before the first one is restored.
+		previous = &d.restore.Status
+	}
+	if previous != nil {
+		d.setRestore(&restore{
+			Status:  *previous,
+			At:      time.Unix(params.Status.ClearAt, 0),
+			Applied: params.Status,
+		})
+	} else {
+		d.setRestore(nil)
+	}
+	d.mu.Unlock()
+
+	status := params.Status
+	status.User = d.Auth.User
+	d.cache(&status)
+	d.record(status, history.SourceNsc)
+	return nil
+}
+
+func (d *Daemon) Clear() error {
+	if err := ocs.ClearStatusMessage(d.Auth); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.setRestore(nil)
+	var status *ocs.UserStatus
+	if d.status != nil {
+		status = &ocs.UserStatus{User: d.status.User, Status: d.status.Status}
+	}
+	d.mu.Unlock()
+
+	if status != nil {
+		d.cache(status)
+	} else if err := d.refresh(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	status = d.status
+	d.mu.Unlock()
+
+	if status != nil {
+		d.record(*status, history.SourceNsc)
+	}
+	return nil
+}
+
+func (d *Daemon) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	var writeMu sync.Mutex
+	enc := json.NewEncoder(conn)
+	write := func(res Response) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return enc.Encode(res)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			write(Response{Error: fmt.Sprintf("Invalid request: %s", err)})
+			continue
+		}
+
+		// Subscribe before fetching the current status so no change is missed.
+		var updates chan *ocs.UserStatus
+		if req.Method == MethodSubscribe {
+			updates = d.subscribe()
+			defer d.unsubscribe(updates)
+		}
+
+		result, err := d.handle(req)
+		res := Response{Id: req.Id}
+		if err != nil {
+			res.Error = err.Error()
+			res.Offline = ocs.IsTransportError(err)
+		} else if res.Result, err = json.Marshal(result); err != nil {
+			res.Error = err.Error()
+		}
+
+		if err := write(res); err != nil {
+			return
+		}
+
+		if updates != nil {
+			if res.Error == "" {
+				d.stream(ctx, conn, updates, write)
+			}
+			return
+		}
+	}
+}
+
+func (d *Daemon) subscribe() chan *ocs.UserStatus {
+	updates := make(chan *ocs.UserStatus, 8)
+	d.mu.Lock()
+	d.subscribers[updates] = struct{}{}
+	d.mu.Unlock()
+	return updates
+}
+
+func (d *Daemon) unsubscribe(updates chan *ocs.UserStatus) {
+	d.mu.Lock()
+	delete(d.subscribers, updates)
+	d.mu.Unlock()
+}
+
+func (d *Daemon) handle(req Request) (any, error) {
+	switch req.Method {
+	case MethodGet, MethodSubscribe:
+		return d.cached()
+	case MethodSet:
+		var params SetParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("Invalid params: %s", err)
+		}
+		return nil, d.Set(params)
+	case MethodClear:
+		return nil, d.Clear()
+	default:
+		return nil, fmt.Errorf("Unknown method: %s", req.Method)
+	}
+}
+
+// stream pushes status changes to a subscribed connection until it is closed
+// by either side.
+func (d *Daemon) stream(ctx context.Context, conn net.Conn, updates chan *ocs.UserStatus, write func(Response) error) {
+	closed := make(chan struct{})
+	go func() {
+		// Subscribers do not send anything, so a read only returns once the
+		// connection is closed.
+		conn.Read(make([]byte, 1))
+		close(closed)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-closed:
+			return
+		case status := <-updates:
+			result, err := json.Marshal(status)
+			if err != nil {
+				continue
+			}
+
+			if err := write(Response{Method: notificationStatus, Result: result}); err != nil {
+				return
+			}
+		}
+	}
+}
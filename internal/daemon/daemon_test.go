@@ -0,0 +1,446 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/meeting"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer implements the subset of the user status API used by the daemon.
+type fakeServer struct {
+	mu     sync.Mutex
+	status ocs.UserStatus
+
+	// offline drops all connections as if the server was not reachable.
+	offline bool
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.offline {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/user_status"):
+		json.NewEncoder(w).Encode(map[string]any{"ocs": map[string]any{"data": map[string]any{
+			"userId":  "alice",
+			"status":  s.status.Status,
+			"icon":    s.status.Icon,
+			"message": s.status.Message,
+			"clearAt": s.status.ClearAt,
+		}}})
+	case r.Method == "PUT" && strings.HasSuffix(r.URL.Path, "/user_status/status"):
+		var status ocs.Status
+		json.NewDecoder(r.Body).Decode(&status)
+		s.status.Status = status.StatusType
+	case r.Method == "PUT" && strings.HasSuffix(r.URL.Path, "/message/custom"):
+		var message ocs.StatusMessage
+		json.NewDecoder(r.Body).Decode(&message)
+		s.status.Icon = message.StatusIcon
+		s.status.Message = message.Message
+		s.status.ClearAt = message.ClearAt
+	case r.Method == "DELETE" && strings.HasSuffix(r.URL.Path, "/message"):
+		s.status.Icon = ""
+		s.status.Message = ""
+		s.status.ClearAt = 0
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func startDaemon(t *testing.T, fake *fakeServer) (*Daemon, string) {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	socketPath := filepath.Join(t.TempDir(), "daemon.sock")
+	d := &Daemon{
+		Auth:         ocs.Auth{ServerBaseUrl: server.URL, User: "alice"},
+		SocketPath:   socketPath,
+		PollInterval: time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool {
+		client, err := DialPath(socketPath)
+		if err == nil {
+			client.Close()
+		}
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	return d, socketPath
+}
+
+func TestGetSetClear(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online", Message: "Hello"}}
+	_, socketPath := startDaemon(t, fake)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	status, err := client.Get()
+	assert.NoError(err)
+	assert.Equal(&ocs.UserStatus{User: "alice", Status: "online", Message: "Hello"}, status)
+
+	err = client.Set(SetParams{Status: ocs.UserStatus{Status: "dnd", Icon: "🎧", Message: "Focus"}})
+	assert.NoError(err)
+	assert.Equal(ocs.UserStatus{Status: "dnd", Icon: "🎧", Message: "Focus"}, fake.status)
+
+	status, err = client.Get()
+	assert.NoError(err)
+	assert.Equal(&ocs.UserStatus{User: "alice", Status: "dnd", Icon: "🎧", Message: "Focus"}, status)
+
+	err = client.Clear()
+	assert.NoError(err)
+	assert.Equal(ocs.UserStatus{Status: "dnd"}, fake.status)
+}
+
+func TestOfflineErrors(t *testing.T) {
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online"}}
+	_, socketPath := startDaemon(t, fake)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	fake.mu.Lock()
+	fake.offline = true
+	fake.mu.Unlock()
+
+	err = client.Set(SetParams{Status: ocs.UserStatus{Status: "dnd", Message: "Focus"}})
+	assert.Error(t, err)
+	assert.True(t, ocs.IsTransportError(err))
+}
+
+func TestHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online"}}
+	d, socketPath := startDaemon(t, fake)
+	d.History = &history.Log{Path: filepath.Join(t.TempDir(), "history.jsonl")}
+	d.RecordObserved = true
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.Set(SetParams{Status: ocs.UserStatus{Status: "dnd", Icon: "🎧", Message: "Focus"}}))
+	require.NoError(t, client.Clear())
+
+	// Unchanged statuses are not recorded again.
+	d.Tick(time.Now())
+	fake.mu.Lock()
+	fake.status = ocs.UserStatus{Status: "away", Message: "Set in the web interface"}
+	fake.mu.Unlock()
+	d.Tick(time.Now())
+
+	entries, err := d.History.Read()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(history.SourceNsc, entries[0].Source)
+	assert.Equal("Focus", entries[0].Status.Message)
+	assert.Equal(ocs.UserStatus{User: "alice", Status: "dnd"}, entries[1].Status)
+	assert.Equal(history.SourceObserved, entries[2].Source)
+	assert.Equal("Set in the web interface", entries[2].Status.Message)
+}
+
+func TestRestoreAfterTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}}
+	d, socketPath := startDaemon(t, fake)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	clearAt := time.Now().Add(time.Hour)
+	err = client.Set(SetParams{
+		Status:  ocs.UserStatus{Status: "away", Icon: "🍔", Message: "Lunch", ClearAt: clearAt.Unix()},
+		Restore: true,
+	})
+	assert.NoError(err)
+
+	d.Tick(clearAt.Add(-time.Minute))
+	assert.Equal("Lunch", fake.status.Message)
+
+	d.Tick(clearAt)
+	assert.Equal(ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}, fake.status)
+}
+
+func TestRestoreWhileOffline(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}}
+	d, socketPath := startDaemon(t, fake)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	clearAt := time.Now().Add(time.Hour)
+	err = client.Set(SetParams{
+		Status:  ocs.UserStatus{Status: "away", Icon: "🍔", Message: "Lunch", ClearAt: clearAt.Unix()},
+		Restore: true,
+	})
+	assert.NoError(err)
+
+	// The restore is retried once the server is reachable again.
+	fake.mu.Lock()
+	fake.offline = true
+	fake.mu.Unlock()
+	d.Tick(clearAt)
+
+	fake.mu.Lock()
+	fake.offline = false
+	fake.mu.Unlock()
+	d.Tick(clearAt.Add(time.Minute))
+	assert.Equal(ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}, fake.status)
+}
+
+func TestRestoreKeepsChangedStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}}
+	d, socketPath := startDaemon(t, fake)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	clearAt := time.Now().Add(time.Hour)
+	err = client.Set(SetParams{
+		Status:  ocs.UserStatus{Status: "dnd", Icon: "📅", Message: "In a meeting", ClearAt: clearAt.Unix()},
+		Restore: true,
+	})
+	assert.NoError(err)
+
+	// The status was changed in the web interface.
+	fake.mu.Lock()
+	fake.status = ocs.UserStatus{Status: "away", Icon: "🍔", Message: "Lunch"}
+	fake.mu.Unlock()
+
+	d.Tick(clearAt)
+	assert.Equal(ocs.UserStatus{Status: "away", Icon: "🍔", Message: "Lunch"}, fake.status)
+}
+
+func TestRestoreAcrossRuns(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	restorePath := filepath.Join(t.TempDir(), "restore.json")
+	run := func() *Daemon {
+		return &Daemon{
+			Auth:        ocs.Auth{ServerBaseUrl: server.URL, User: "alice"},
+			RestorePath: restorePath,
+		}
+	}
+
+	// Every run with --once applies the meeting status again.
+	clearAt := time.Now().Add(time.Hour)
+	meeting := ocs.UserStatus{Status: "dnd", Icon: "📅", Message: "In a meeting", ClearAt: clearAt.Unix()}
+	for range 2 {
+		assert.NoError(run().Set(SetParams{Status: meeting, Restore: true}))
+	}
+	assert.Equal("In a meeting", fake.status.Message)
+
+	run().Tick(clearAt.Add(-time.Minute))
+	assert.Equal("In a meeting", fake.status.Message)
+
+	run().Tick(clearAt)
+	assert.Equal(ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}, fake.status)
+	assert.NoFileExists(restorePath)
+}
+
+func TestScheduledStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online"}}
+	d, socketPath := startDaemon(t, fake)
+
+	store := schedule.Store{Path: filepath.Join(t.TempDir(), "schedules.json")}
+	at := time.Now().Add(time.Hour)
+	_, err := store.Add(at, ocs.UserStatus{Status: "away", Icon: "🍔", Message: "Lunch"})
+	require.NoError(t, err)
+
+	d.Schedule = &schedule.Runner{
+		Store:   store,
+		CatchUp: schedule.CatchUpLatest,
+		Grace:   schedule.DefaultGrace,
+		Apply: func(status ocs.UserStatus) error {
+			return d.Set(SetParams{Status: status})
+		},
+	}
+
+	d.Tick(at.Add(-time.Minute))
+	assert.Equal("online", fake.status.Status)
+
+	d.Tick(at)
+	assert.Equal(ocs.UserStatus{Status: "away", Icon: "🍔", Message: "Lunch"}, fake.status)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	status, err := client.Get()
+	assert.NoError(err)
+	assert.Equal("Lunch", status.Message)
+}
+
+func TestMeetingStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}}
+	d, _ := startDaemon(t, fake)
+
+	// Two meetings back to back.
+	start := time.Now().Add(time.Hour).Truncate(time.Minute).UTC()
+	calendarPath := filepath.Join(t.TempDir(), "work.ics")
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:1\r\nSUMMARY:Standup\r\n" +
+		"DTSTART:" + start.Format("20060102T150405Z") + "\r\nDURATION:PT30M\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:2\r\nSUMMARY:Review\r\n" +
+		"DTSTART:" + start.Add(30*time.Minute).Format("20060102T150405Z") + "\r\nDURATION:PT30M\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	require.NoError(t, os.WriteFile(calendarPath, []byte(ics), 0600))
+
+	d.Meetings = &meeting.Watcher{
+		Sources:         []meeting.Source{{Name: "work", Location: calendarPath, TimeZone: time.UTC}},
+		RefreshInterval: time.Hour,
+		Apply: func(status ocs.UserStatus) error {
+			return d.Set(SetParams{Status: status, Restore: true})
+		},
+	}
+
+	d.Tick(start.Add(-time.Minute))
+	assert.Equal("online", fake.status.Status)
+
+	d.Tick(start)
+	assert.Equal(ocs.UserStatus{
+		Status:  "dnd",
+		Icon:    "📅",
+		Message: "In a meeting: Standup",
+		ClearAt: start.Add(30 * time.Minute).Unix(),
+	}, fake.status)
+
+	d.Tick(start.Add(30 * time.Minute))
+	assert.Equal("In a meeting: Review", fake.status.Message)
+
+	d.Tick(start.Add(time.Hour))
+	assert.Equal(ocs.UserStatus{Status: "online", Icon: "🏡", Message: "Working from home"}, fake.status)
+}
+
+func TestFollowWorkingHours(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online"}}
+	d, _ := startDaemon(t, fake)
+
+	cal := calendar.Default()
+	cal.Location = time.UTC
+	d.Calendar = &cal
+	d.OffHoursStatus = "invisible"
+
+	// Wednesday
+	d.Tick(time.Date(2024, 6, 5, 16, 0, 0, 0, time.UTC))
+	assert.Equal("online", fake.status.Status)
+
+	d.Tick(time.Date(2024, 6, 5, 17, 0, 0, 0, time.UTC))
+	assert.Equal("invisible", fake.status.Status)
+
+	d.Tick(time.Date(2024, 6, 6, 9, 0, 0, 0, time.UTC))
+	assert.Equal("online", fake.status.Status)
+
+	// A manually chosen status is kept when the working hours end.
+	fake.status.Status = "dnd"
+	d.Tick(time.Date(2024, 6, 6, 17, 0, 0, 0, time.UTC))
+	assert.Equal("dnd", fake.status.Status)
+
+	// Going online manually is not undone before the working hours start
+	// again.
+	fake.status.Status = "online"
+	d.Tick(time.Date(2024, 6, 6, 18, 0, 0, 0, time.UTC))
+	assert.Equal("online", fake.status.Status)
+}
+
+func TestSubscribe(t *testing.T) {
+	fake := &fakeServer{status: ocs.UserStatus{Status: "online"}}
+	_, socketPath := startDaemon(t, fake)
+
+	subscriber, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer subscriber.Close()
+
+	statuses := make(chan *ocs.UserStatus, 4)
+	go subscriber.Subscribe(func(status *ocs.UserStatus) {
+		statuses <- status
+	})
+	assert.Equal(t, "online", (<-statuses).Status)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.Set(SetParams{Status: ocs.UserStatus{Status: "away", Message: "Brb"}})
+	assert.NoError(t, err)
+	assert.Equal(t, &ocs.UserStatus{User: "alice", Status: "away", Message: "Brb"}, <-statuses)
+}
+
+func TestNotifiesSystemd(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+	t.Setenv("WATCHDOG_USEC", "20000")
+
+	fake := &fakeServer{status: ocs.UserStatus{Status: "away", Icon: "🍔", Message: "Lunch"}}
+	startDaemon(t, fake)
+
+	var states []string
+	buf := make([]byte, 1024)
+	for range 3 {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		states = append(states, string(buf[:n]))
+	}
+	assert.Equal(t, []string{"STATUS=away 🍔 Lunch", "READY=1", "WATCHDOG=1"}, states)
+}
@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+// The daemon speaks newline delimited JSON over a Unix socket. Every request
+// is answered by a response with the same id. After a successful subscribe
+// request the daemon pushes a notification whenever the status changes.
+const (
+	MethodGet       = "get"
+	MethodSet       = "set"
+	MethodClear     = "clear"
+	MethodSubscribe = "subscribe"
+
+	notificationStatus = "status"
+)
+
+type Request struct {
+	Id     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type Response struct {
+	Id     int             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+
+	// Offline is set if the error was caused by a request that did not reach
+	// the server.
+	Offline bool `json:"offline,omitempty"`
+}
+
+type SetParams struct {
+	Status ocs.UserStatus `json:"status"`
+
+	// Restore the previous status once the new status is cleared at
+	// Status.ClearAt.
+	Restore bool `json:"restore,omitempty"`
+}
+
+// SocketPath returns the path of the control socket of the daemon serving
+// the current profile.
+func SocketPath() (string, error) {
+	if profile := ocs.Profile(); profile != "" {
+		return xdg.RuntimeFile(fmt.Sprintf("nsc/daemon-%s.sock", profile))
+	}
+
+	return xdg.RuntimeFile("nsc/daemon.sock")
+}
+
+// RestorePath returns the path of the file keeping the pending restore of the
+// current profile.
+func RestorePath() (string, error) {
+	if profile := ocs.Profile(); profile != "" {
+		return xdg.StateFile(fmt.Sprintf("nsc/restore-%s.json", profile))
+	}
+
+	return xdg.StateFile("nsc/restore.json")
+}
@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+type UnitOptions struct {
+	Executable string
+	Profile    string
+
+	// TimerInterval enables an additional timer that runs the periodic jobs
+	// of the daemon on its own if it is not running.
+	TimerInterval time.Duration
+}
+
+type Unit struct {
+	Name    string
+	Content string
+}
+
+var serviceTemplate = template.Must(template.New("service").Parse(`[Unit]
+Description=Nextcloud status daemon{{if .Profile}} ({{.Profile}}){{end}}
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=notify
+ExecStart={{.ExecStart}} daemon
+{{- if .Profile}}
+Environment={{.ProfileEnv}}={{.Profile}}
+{{- end}}
+Restart=on-failure
+RestartSec=30s
+WatchdogSec=5min
+
+[Install]
+WantedBy=default.target
+`))
+
+var onceServiceTemplate = template.Must(template.New("once").Parse(`[Unit]
+Description=Periodic jobs of the Nextcloud status daemon{{if .Profile}} ({{.Profile}}){{end}}
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart={{.ExecStart}} daemon --once
+{{- if .Profile}}
+Environment={{.ProfileEnv}}={{.Profile}}
+{{- end}}
+`))
+
+var timerTemplate = template.Must(template.New("timer").Parse(`[Unit]
+Description=Run periodic jobs of the Nextcloud status daemon{{if .Profile}} ({{.Profile}}){{end}}
+
+[Timer]
+OnStartupSec=1min
+OnUnitActiveSec={{.Interval}}
+
+[Install]
+WantedBy=timers.target
+`))
+
+// UnitName returns the name of the systemd user service of the daemon
+// serving the given profile without a suffix.
+func UnitName(profile string) string {
+	if profile == "" {
+		return "nsc-daemon"
+	}
+
+	return "nsc-daemon-" + profile
+}
+
+// Units renders the systemd user units of the daemon.
+func Units(options UnitOptions) ([]Unit, error) {
+	if options.Profile != "" && !ocs.ValidProfile(options.Profile) {
+		return nil, fmt.Errorf("Invalid profile %q", options.Profile)
+	}
+	if options.TimerInterval != 0 && options.TimerInterval < time.Second {
+		return nil, fmt.Errorf("Invalid timer interval %s, expected at least 1s", options.TimerInterval)
+	}
+	execStart := options.Executable
+	if strings.ContainsAny(execStart, " \t\"") {
+		execStart = fmt.Sprintf("%q", execStart)
+	}
+
+	data := map[string]any{
+		"ExecStart":  execStart,
+		"Profile":    options.Profile,
+		"ProfileEnv": ocs.ProfileEnv,
+		"Interval":   fmt.Sprintf("%ds", int64(options.TimerInterval.Seconds())),
+	}
+
+	name := UnitName(options.Profile)
+	templates := map[string]*template.Template{
+		name + ".service": serviceTemplate,
+	}
+	if options.TimerInterval > 0 {
+		templates[name+"-once.service"] = onceServiceTemplate
+		templates[name+"-once.timer"] = timerTemplate
+	}
+
+	var units []Unit
+	for _, unitName := range slices.Sorted(maps.Keys(templates)) {
+		var content strings.Builder
+		if err := templates[unitName].Execute(&content, data); err != nil {
+			return nil, err
+		}
+
+		units = append(units, Unit{Name: unitName, Content: content.String()})
+	}
+
+	return units, nil
+}
@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnits(t *testing.T) {
+	assert := assert.New(t)
+
+	units, err := Units(UnitOptions{Executable: "/usr/bin/nsc"})
+	assert.NoError(err)
+	assert.Len(units, 1)
+	assert.Equal("nsc-daemon.service", units[0].Name)
+	assert.Contains(units[0].Content, "Type=notify\nExecStart=/usr/bin/nsc daemon\nRestart=on-failure\n")
+
+	units, err = Units(UnitOptions{
+		Executable:    "/home/alice/my bin/nsc",
+		Profile:       "work",
+		TimerInterval: 5 * time.Minute,
+	})
+	assert.NoError(err)
+	assert.Len(units, 3)
+	assert.Equal("nsc-daemon-work-once.service", units[0].Name)
+	assert.Contains(units[0].Content, "ExecStart=\"/home/alice/my bin/nsc\" daemon --once\nEnvironment=NSC_PROFILE=work\n")
+	assert.Equal("nsc-daemon-work-once.timer", units[1].Name)
+	assert.Contains(units[1].Content, "OnUnitActiveSec=300s\n")
+	assert.Equal("nsc-daemon-work.service", units[2].Name)
+	assert.Contains(units[2].Content, "ExecStart=\"/home/alice/my bin/nsc\" daemon\nEnvironment=NSC_PROFILE=work\n")
+
+	_, err = Units(UnitOptions{Executable: "/usr/bin/nsc", TimerInterval: 500 * time.Millisecond})
+	assert.Error(err)
+	_, err = Units(UnitOptions{Executable: "/usr/bin/nsc", Profile: "../work"})
+	assert.Error(err)
+}
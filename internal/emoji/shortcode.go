@@ -0,0 +1,192 @@
+package emoji
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// aliases are the common shortcodes used by GitHub, Slack and most chat
+// apps, which often differ from the description of the emoji.
+var aliases = map[string][]string{
+	"☕":  {"coffee"},
+	"🎉":  {"tada"},
+	"👍":  {"+1", "thumbsup"},
+	"👎":  {"-1", "thumbsdown"},
+	"❤️": {"heart"},
+	"😄":  {"smile"},
+	"😃":  {"smiley"},
+	"😁":  {"grin"},
+	"😂":  {"joy"},
+	"😆":  {"laughing"},
+	"🤣":  {"rofl"},
+	"😉":  {"wink"},
+	"😊":  {"blush"},
+	"😅":  {"sweat_smile"},
+	"😍":  {"heart_eyes"},
+	"😎":  {"sunglasses"},
+	"😛":  {"stuck_out_tongue"},
+	"🤔":  {"thinking"},
+	"🤗":  {"hugs"},
+	"😭":  {"sob"},
+	"😢":  {"cry"},
+	"😡":  {"rage"},
+	"😠":  {"angry"},
+	"😴":  {"sleeping"},
+	"😷":  {"mask"},
+	"🤷":  {"shrug"},
+	"🤦":  {"facepalm"},
+	"🏃":  {"runner"},
+	"🚶":  {"walking"},
+	"👋":  {"wave"},
+	"👏":  {"clap"},
+	"🙏":  {"pray"},
+	"💪":  {"muscle"},
+	"🙌":  {"raised_hands"},
+	"💯":  {"100"},
+	"☀️": {"sunny"},
+	"☔":  {"umbrella"},
+	"🏖️": {"beach_umbrella"},
+	"🍺":  {"beer"},
+	"🍻":  {"beers"},
+	"🍔":  {"burger"},
+	"🎂":  {"birthday"},
+	"🎁":  {"gift"},
+	"🚗":  {"car"},
+	"🚲":  {"bike"},
+	"💻":  {"computer"},
+	"☎️": {"phone"},
+	"🎧":  {"headphones"},
+	"📅":  {"date"},
+	"💡":  {"bulb"},
+	"📝":  {"pencil"},
+	"✅":  {"white_check_mark"},
+	"✔️": {"heavy_check_mark"},
+	"❌":  {"x"},
+	"⌛":  {"hourglass"},
+	"🐶":  {"dog"},
+	"🐕":  {"dog2"},
+	"🐱":  {"cat"},
+	"🐈":  {"cat2"},
+	"🕶️": {"dark_sunglasses"},
+	"☂️": {"open_umbrella"},
+	"✏️": {"pencil2"},
+}
+
+// aliased are the aliases, which replace the shortcodes derived from the
+// descriptions of other emojis, e.g. ":cat:" for 🐱 rather than 🐈.
+var aliased = func() map[string]bool {
+	aliased := map[string]bool{}
+	for _, names := range aliases {
+		for _, name := range names {
+			aliased[":"+name+":"] = true
+		}
+	}
+	return aliased
+}()
+
+// symbolNames are the names of symbols in descriptions, e.g. "keycap: #".
+var symbolNames = map[rune]string{
+	'#': "hash",
+	'*': "asterisk",
+}
+
+// Shortcode returns the shortcode of the emoji derived from its description,
+// e.g. ":hot_beverage:" for "hot beverage".
+func Shortcode(e Emoji) string {
+	var b strings.Builder
+	separate := false
+	for _, r := range strings.ToLower(e.Description) {
+		name, ok := symbolNames[r]
+		if !ok && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			separate = b.Len() > 0
+			continue
+		}
+
+		if separate {
+			b.WriteRune('_')
+			separate = false
+		}
+		if ok {
+			b.WriteString(name)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return ":" + b.String() + ":"
+}
+
+// Shortcodes returns all shortcodes of the emoji, its common aliases (e.g.
+// ":coffee:") followed by the one derived from its description unless it is
+// the alias of another emoji.
+func Shortcodes(e Emoji) []string {
+	var shortcodes []string
+	for _, alias := range aliases[e.Emoji] {
+		shortcodes = append(shortcodes, ":"+alias+":")
+	}
+
+	if shortcode := Shortcode(e); !aliased[shortcode] {
+		shortcodes = append(shortcodes, shortcode)
+	}
+	return shortcodes
+}
+
+// Lookup returns the emoji with the given shortcode or alias.
+func Lookup(shortcode string) (Emoji, bool) {
+	for _, e := range Emojis {
+		if slices.Contains(Shortcodes(e), shortcode) {
+			return e, true
+		}
+	}
+
+	return Emoji{}, false
+}
+
+// Find returns the emoji with the given character.
+func Find(value string) (Emoji, bool) {
+	for _, e := range Emojis {
+		if e.Emoji == value {
+			return e, true
+		}
+	}
+
+	return Emoji{}, false
+}
+
+// Resolve replaces a shortcode with its emoji. Other values are returned as
+// is.
+func Resolve(value string) string {
+	if len(value) < 3 || !strings.HasPrefix(value, ":") || !strings.HasSuffix(value, ":") {
+		return value
+	}
+
+	if e, ok := Lookup(value); ok {
+		return e.Emoji
+	}
+	return value
+}
+
+// Match is an emoji found by one of its shortcodes.
+type Match struct {
+	Emoji
+	Shortcode string
+}
+
+// Search returns the emojis with a shortcode or alias of which one of the
+// words starts with the given prefix. A leading colon is ignored.
+func Search(prefix string) []Match {
+	prefix = strings.ToLower(strings.TrimPrefix(prefix, ":"))
+
+	var matches []Match
+	for _, e := range Emojis {
+		for _, shortcode := range Shortcodes(e) {
+			name := strings.Trim(shortcode, ":")
+			if strings.HasPrefix(name, prefix) || strings.Contains(name, "_"+prefix) {
+				matches = append(matches, Match{Emoji: e, Shortcode: shortcode})
+			}
+		}
+	}
+
+	return matches
+}
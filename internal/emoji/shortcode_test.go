@@ -0,0 +1,64 @@
+package emoji
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortcode(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(":hot_beverage:", Shortcode(Emoji{Emoji: "☕", Description: "hot beverage"}))
+	assert.Equal(":flag_germany:", Shortcode(Emoji{Emoji: "🇩🇪", Description: "flag: Germany"}))
+
+	assert.Equal("☕", Resolve(":hot_beverage:"))
+	assert.Equal(":unknown:", Resolve(":unknown:"))
+	assert.Equal("☕", Resolve("☕"))
+
+	e, ok := Find("☕")
+	assert.True(ok)
+	assert.Equal("hot beverage", e.Description)
+
+	matches := Search(":bever")
+	assert.Contains(matches, Match{Emoji: Emoji{Emoji: "☕", Description: "hot beverage"}, Shortcode: ":hot_beverage:"})
+	for _, match := range matches {
+		assert.Contains(match.Shortcode, "bever")
+	}
+}
+
+func TestAliases(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("☕", Resolve(":coffee:"))
+	assert.Equal("🎉", Resolve(":tada:"))
+	assert.Equal("👍", Resolve(":+1:"))
+	assert.Equal("👍", Resolve(":thumbs_up:"))
+	assert.Equal([]string{":coffee:", ":hot_beverage:"}, Shortcodes(Emoji{Emoji: "☕", Description: "hot beverage"}))
+
+	assert.Contains(Search(":coff"), Match{Emoji: Emoji{Emoji: "☕", Description: "hot beverage"}, Shortcode: ":coffee:"})
+
+	// Aliases take precedence over derived shortcodes.
+	assert.Equal("☔", Resolve(":umbrella:"))
+	assert.Equal("☂️", Resolve(":open_umbrella:"))
+	assert.Equal("🐱", Resolve(":cat:"))
+	assert.Equal("#️⃣", Resolve(":keycap_hash:"))
+	assert.Equal("*️⃣", Resolve(":keycap_asterisk:"))
+
+	// Every alias belongs to a known emoji.
+	for value := range aliases {
+		_, ok := Find(value)
+		assert.True(ok, value)
+	}
+}
+
+func TestShortcodesAreUnique(t *testing.T) {
+	seen := map[string]string{}
+	for _, e := range Emojis {
+		for _, shortcode := range Shortcodes(e) {
+			other, ok := seen[shortcode]
+			assert.False(t, ok, "%s is used by %s and %s", shortcode, other, e.Emoji)
+			seen[shortcode] = e.Emoji
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package heartbeat
+
+import (
+	"context"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const (
+	StatusOnline = "online"
+	StatusAway   = "away"
+)
+
+type Heartbeat struct {
+	Auth      ocs.Auth
+	Source    Source
+	Interval  time.Duration
+	AwayAfter time.Duration
+
+	// OnBeat is called after every heartbeat with the reported activity and
+	// the resulting status or error.
+	OnBeat func(activity string, status *ocs.UserStatus, err error)
+}
+
+// Beat reports the current activity once.
+func (h Heartbeat) Beat() (string, *ocs.UserStatus, error) {
+	idle, err := h.Source.Idle()
+	if err != nil {
+		return "", nil, err
+	}
+
+	activity := StatusOnline
+	if idle >= h.AwayAfter {
+		activity = StatusAway
+	}
+
+	status, err := ocs.Heartbeat(h.Auth, activity)
+	return activity, status, err
+}
+
+// Run sends a heartbeat immediately and then on every interval until the
+// context is cancelled.
+func (h Heartbeat) Run(ctx context.Context) error {
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+
+	for {
+		activity, status, err := h.Beat()
+		if h.OnBeat != nil {
+			h.OnBeat(activity, status, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
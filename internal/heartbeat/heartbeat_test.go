@@ -0,0 +1,70 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSource time.Duration
+
+func (s fakeSource) Idle() (time.Duration, error) {
+	return time.Duration(s), nil
+}
+
+func TestParseIdle(t *testing.T) {
+	assert := assert.New(t)
+
+	idle, err := parseIdle("1500\n")
+	assert.NoError(err)
+	assert.Equal(1500*time.Millisecond, idle)
+
+	idle, err = parseIdle("2m30s")
+	assert.NoError(err)
+	assert.Equal(150*time.Second, idle)
+
+	_, err = parseIdle("soon")
+	assert.Error(err)
+}
+
+func TestBeat(t *testing.T) {
+	assert := assert.New(t)
+
+	var reported []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Status string `json:"status"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		reported = append(reported, r.Method+" "+r.URL.Path+" "+body.Status)
+		fmt.Fprintf(w, `{"ocs":{"data":{"userId":"alice","status":"%s"}}}`, body.Status)
+	}))
+	defer server.Close()
+
+	h := Heartbeat{
+		Auth:      ocs.Auth{ServerBaseUrl: server.URL, User: "alice"},
+		AwayAfter: 5 * time.Minute,
+	}
+
+	h.Source = fakeSource(time.Minute)
+	activity, status, err := h.Beat()
+	assert.NoError(err)
+	assert.Equal(StatusOnline, activity)
+	assert.Equal(&ocs.UserStatus{User: "alice", Status: StatusOnline}, status)
+
+	h.Source = fakeSource(10 * time.Minute)
+	activity, _, err = h.Beat()
+	assert.NoError(err)
+	assert.Equal(StatusAway, activity)
+
+	assert.Equal([]string{
+		"PUT /ocs/v2.php/apps/user_status/api/v1/heartbeat online",
+		"PUT /ocs/v2.php/apps/user_status/api/v1/heartbeat away",
+	}, reported)
+}
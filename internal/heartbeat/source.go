@@ -0,0 +1,94 @@
+package heartbeat
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source reports how long the user has been inactive.
+type Source interface {
+	Idle() (time.Duration, error)
+}
+
+// TTYSource derives the idle time from the last access time of the user's
+// pseudo terminals. Reading input from a terminal updates its access time.
+type TTYSource struct {
+	Dir string
+}
+
+func NewTTYSource() TTYSource {
+	return TTYSource{Dir: "/dev/pts"}
+}
+
+func (s TTYSource) Idle() (time.Duration, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return 0, err
+	}
+
+	uid := os.Getuid()
+	var lastAccess time.Time
+	for _, entry := range entries {
+		if entry.Name() == "ptmx" {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(s.Dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		owner, atime, ok := ownerAndAccessTime(info)
+		if !ok || owner != uid {
+			continue
+		}
+
+		if atime.After(lastAccess) {
+			lastAccess = atime
+		}
+	}
+
+	if lastAccess.IsZero() {
+		return 0, fmt.Errorf("No terminal owned by you was found in %s", s.Dir)
+	}
+
+	return max(0, time.Since(lastAccess)), nil
+}
+
+// CommandSource runs an external command (e.g. xprintidle) that prints the
+// idle time either in milliseconds or as a Go duration.
+type CommandSource struct {
+	Command []string
+}
+
+func (s CommandSource) Idle() (time.Duration, error) {
+	if len(s.Command) == 0 {
+		return 0, fmt.Errorf("No idle command configured")
+	}
+
+	out, err := exec.Command(s.Command[0], s.Command[1:]...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to run idle command: %s", err)
+	}
+
+	return parseIdle(string(out))
+}
+
+func parseIdle(out string) (time.Duration, error) {
+	out = strings.TrimSpace(out)
+	if millis, err := strconv.ParseInt(out, 10, 64); err == nil {
+		return time.Duration(millis) * time.Millisecond, nil
+	}
+
+	idle, err := time.ParseDuration(out)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse idle time %q", out)
+	}
+
+	return idle, nil
+}
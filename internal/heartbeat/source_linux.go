@@ -0,0 +1,16 @@
+package heartbeat
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func ownerAndAccessTime(info os.FileInfo) (int, time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+
+	return int(stat.Uid), time.Unix(stat.Atim.Sec, stat.Atim.Nsec), true
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package heartbeat
+
+import (
+	"os"
+	"time"
+)
+
+func ownerAndAccessTime(info os.FileInfo) (int, time.Time, bool) {
+	return 0, time.Time{}, false
+}
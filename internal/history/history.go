@@ -0,0 +1,161 @@
+// Package history logs status changes so they can be browsed, reported on and
+// applied again.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const (
+	// SourceNsc marks changes made through nsc or its daemon.
+	SourceNsc = "nsc"
+	// SourceObserved marks changes the daemon noticed while polling, e.g.
+	// made in the web interface or cleared by the server.
+	SourceObserved = "observed"
+)
+
+type Entry struct {
+	// Id is the line number of the entry in the log. It is not stored.
+	Id int `json:"-"`
+
+	At     time.Time `json:"at"`
+	Source string    `json:"source"`
+
+	// Status is the status after the change. Status.Status is empty if the
+	// message was cleared while the status was unknown.
+	Status ocs.UserStatus `json:"status"`
+}
+
+// Log appends the entries to a file with one JSON object per line.
+type Log struct {
+	Path string
+}
+
+// DefaultLog returns the log of the current profile in the XDG state
+// directory.
+func DefaultLog() (Log, error) {
+	name := "nsc/history.jsonl"
+	if profile := ocs.Profile(); profile != "" {
+		name = filepath.Join("nsc", "profiles", profile, "history.jsonl")
+	}
+
+	path, err := xdg.StateFile(name)
+	if err != nil {
+		return Log{}, err
+	}
+
+	return Log{Path: path}, nil
+}
+
+func (l Log) Append(entry Entry) error {
+	entryJson, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// A single write of a line is atomic with O_APPEND, so concurrent
+	// writers do not interleave.
+	file, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(entryJson, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Read returns all entries from the oldest to the newest.
+func (l Log) Read() ([]Entry, error) {
+	file, err := os.Open(l.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("Invalid entry on line %d: %s", line, err)
+		}
+
+		entry.Id = line
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// Get returns the entry with the given id.
+func (l Log) Get(id int) (Entry, error) {
+	entries, err := l.Read()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	for _, entry := range entries {
+		if entry.Id == id {
+			return entry, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("No history entry with id %d", id)
+}
+
+// Since returns the entries at or after the given time.
+func Since(entries []Entry, since time.Time) []Entry {
+	for i, entry := range entries {
+		if !entry.At.Before(since) {
+			return entries[i:]
+		}
+	}
+	return nil
+}
+
+// RecentEmojis returns up to n distinct emojis from the newest to the oldest
+// entry.
+func RecentEmojis(entries []Entry, n int) []string {
+	return recent(entries, n, func(status ocs.UserStatus) string {
+		return status.Icon
+	})
+}
+
+// RecentMessages returns up to n distinct messages from the newest to the
+// oldest entry.
+func RecentMessages(entries []Entry, n int) []string {
+	return recent(entries, n, func(status ocs.UserStatus) string {
+		return status.Message
+	})
+}
+
+func recent(entries []Entry, n int, value func(ocs.UserStatus) string) []string {
+	var values []string
+	seen := map[string]bool{}
+	for i := len(entries) - 1; i >= 0 && len(values) < n; i-- {
+		v := value(entries[i].Status)
+		if v == "" || seen[v] {
+			continue
+		}
+
+		seen[v] = true
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,64 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+func TestAppendRead(t *testing.T) {
+	assert := assert.New(t)
+	log := Log{Path: filepath.Join(t.TempDir(), "history.jsonl")}
+
+	entries, err := log.Read()
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	require.NoError(t, log.Append(Entry{At: start, Source: SourceNsc, Status: ocs.UserStatus{Status: "dnd", Icon: "🎧", Message: "Focus"}}))
+	require.NoError(t, log.Append(Entry{At: start.Add(time.Hour), Source: SourceObserved, Status: ocs.UserStatus{Status: "dnd"}}))
+
+	entries, err = log.Read()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(1, entries[0].Id)
+	assert.True(start.Equal(entries[0].At))
+	assert.Equal("Focus", entries[0].Status.Message)
+	assert.Equal(2, entries[1].Id)
+	assert.Equal(SourceObserved, entries[1].Source)
+
+	entry, err := log.Get(2)
+	assert.NoError(err)
+	assert.Equal(entries[1], entry)
+	_, err = log.Get(3)
+	assert.Error(err)
+
+	assert.Equal(entries[1:], Since(entries, start.Add(time.Minute)))
+	assert.Empty(Since(entries, start.Add(2*time.Hour)))
+
+	// Invalid lines are reported with their number.
+	file, err := os.OpenFile(log.Path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	file.WriteString("{\n")
+	file.Close()
+	_, err = log.Read()
+	assert.ErrorContains(err, "line 3")
+}
+
+func TestRecent(t *testing.T) {
+	entries := []Entry{
+		{Status: ocs.UserStatus{Icon: "🍔", Message: "Lunch"}},
+		{Status: ocs.UserStatus{Icon: "🎧", Message: "Focus"}},
+		{Status: ocs.UserStatus{}},
+		{Status: ocs.UserStatus{Icon: "🍔", Message: "Late lunch"}},
+	}
+
+	assert.Equal(t, []string{"🍔", "🎧"}, RecentEmojis(entries, 5))
+	assert.Equal(t, []string{"Late lunch", "Focus"}, RecentMessages(entries, 2))
+}
@@ -0,0 +1,71 @@
+package ical
+
+import (
+	"slices"
+	"time"
+)
+
+// Instance is a single occurrence of an event.
+type Instance struct {
+	Event *Event
+	Start time.Time
+	End   time.Time
+}
+
+type occurrenceKey struct {
+	uid   string
+	start int64
+}
+
+// Expand returns the occurrences of the events that overlap [from, to) sorted
+// by their start. Excluded dates and overridden occurrences of recurring
+// events are taken into account.
+func Expand(events []Event, from, to time.Time) []Instance {
+	overrides := map[occurrenceKey]bool{}
+	for _, event := range events {
+		if !event.RecurrenceId.IsZero() {
+			overrides[occurrenceKey{event.UID, event.RecurrenceId.Unix()}] = true
+		}
+	}
+
+	var instances []Instance
+	for i := range events {
+		event := &events[i]
+		duration := event.End.Sub(event.Start)
+
+		if event.RRule == nil || !event.RecurrenceId.IsZero() {
+			if overlaps(event.Start, event.End, from, to) {
+				instances = append(instances, Instance{Event: event, Start: event.Start, End: event.End})
+			}
+			continue
+		}
+
+		for _, start := range event.RRule.Between(from.Add(-duration), to) {
+			if overrides[occurrenceKey{event.UID, start.Unix()}] || slices.ContainsFunc(event.ExDates, start.Equal) {
+				continue
+			}
+
+			end := start.Add(duration)
+			if event.AllDay {
+				// All-day events keep their length in days across daylight
+				// saving time.
+				end = start.AddDate(0, 0, int(duration.Round(24*time.Hour)/(24*time.Hour)))
+			}
+			if overlaps(start, end, from, to) {
+				instances = append(instances, Instance{Event: event, Start: start, End: end})
+			}
+		}
+	}
+
+	slices.SortStableFunc(instances, func(a, b Instance) int {
+		return a.Start.Compare(b.Start)
+	})
+	return instances
+}
+
+func overlaps(start, end, from, to time.Time) bool {
+	if start.Equal(end) {
+		return !start.Before(from) && start.Before(to)
+	}
+	return start.Before(to) && end.After(from)
+}
@@ -0,0 +1,240 @@
+// Package ical reads events from iCalendar data (RFC 5545) and expands their
+// recurrences.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/recurrence"
+)
+
+var durationRegexp = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+
+	// Free is set for events that do not block time (TRANSP:TRANSPARENT).
+	Free      bool
+	Cancelled bool
+
+	RRule   *recurrence.RRule
+	ExDates []time.Time
+
+	// RecurrenceId is set for events that override a single occurrence of
+	// the recurring event with the same UID.
+	RecurrenceId time.Time
+}
+
+type property struct {
+	name   string
+	params string
+	value  string
+}
+
+// Parse reads the events of iCalendar data. Floating times are interpreted in
+// loc. Invalid events are skipped and events with an unsupported recurrence
+// rule only occur once, so a single bad event does not hide the others.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var event *Event
+	var rrule string
+	var invalid error
+	depth := 0
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			event = &Event{}
+			rrule = ""
+			invalid = nil
+			depth = 0
+			continue
+		case event == nil:
+			continue
+		case prop.name == "BEGIN":
+			// Skip nested components like alarms.
+			depth++
+			continue
+		case prop.name == "END" && depth > 0:
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if invalid == nil {
+				invalid = finishEvent(event, rrule)
+			}
+			if invalid != nil {
+				log.Printf("Skipping invalid event: %s", invalid)
+			} else {
+				events = append(events, *event)
+			}
+			event = nil
+			continue
+		}
+
+		if err := event.set(prop, &rrule, loc); err != nil && invalid == nil {
+			invalid = fmt.Errorf("Invalid event %q: %s", event.Summary, err)
+		}
+	}
+
+	return events, nil
+}
+
+// unfold joins lines that are continued on the next line.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line like "DTSTART;TZID=Europe/Berlin:..."
+// into its name, parameters and value. Parameters may contain quoted colons.
+func parseProperty(line string) (property, bool) {
+	quoted := false
+	for i, c := range line {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if quoted {
+				continue
+			}
+
+			name, params, _ := strings.Cut(line[:i], ";")
+			return property{
+				name:   strings.ToUpper(name),
+				params: params,
+				value:  line[i+1:],
+			}, true
+		}
+	}
+
+	return property{}, false
+}
+
+func (e *Event) set(prop property, rrule *string, loc *time.Location) error {
+	var err error
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SUMMARY":
+		e.Summary = unescape(prop.value)
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseTime(prop, loc)
+	case "DTEND":
+		e.End, _, err = parseTime(prop, loc)
+	case "DURATION":
+		var duration time.Duration
+		duration, err = parseDuration(prop.value)
+		// The start is not necessarily known yet.
+		e.End = time.Time{}.Add(duration)
+	case "TRANSP":
+		e.Free = strings.EqualFold(prop.value, "TRANSPARENT")
+	case "STATUS":
+		e.Cancelled = strings.EqualFold(prop.value, "CANCELLED")
+	case "RRULE":
+		*rrule = prop.value
+	case "EXDATE":
+		for _, value := range strings.Split(prop.value, ",") {
+			exDate, _, err := parseTime(property{name: prop.name, params: prop.params, value: value}, loc)
+			if err != nil {
+				return err
+			}
+			e.ExDates = append(e.ExDates, exDate)
+		}
+	case "RECURRENCE-ID":
+		e.RecurrenceId, _, err = parseTime(prop, loc)
+	}
+
+	return err
+}
+
+func finishEvent(e *Event, rrule string) error {
+	if e.Start.IsZero() {
+		return fmt.Errorf("Invalid event %q: missing DTSTART", e.Summary)
+	}
+
+	if e.End.IsZero() {
+		// Events without an end last for a day or no time at all.
+		if e.AllDay {
+			e.End = e.Start.AddDate(0, 0, 1)
+		} else {
+			e.End = e.Start
+		}
+	} else if e.End.Year() == 1 {
+		e.End = e.Start.Add(e.End.Sub(time.Time{}))
+	}
+
+	if rrule != "" {
+		r, err := recurrence.ParseRRule(rrule, e.Start)
+		if err != nil {
+			log.Printf("Event %q only occurs at its start: %s", e.Summary, err)
+			return nil
+		}
+		e.RRule = &r
+	}
+
+	return nil
+}
+
+func parseTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	return recurrence.ParseTime(prop.name+";"+prop.params, prop.value, loc)
+}
+
+// parseDuration parses durations like "PT1H30M" or "P1D".
+func parseDuration(value string) (time.Duration, error) {
+	match := durationRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("Invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var duration time.Duration
+	for i, unit := range units {
+		if match[i+2] != "" {
+			n, _ := strconv.Atoi(match[i+2])
+			duration += time.Duration(n) * unit
+		}
+	}
+
+	if match[1] == "-" {
+		duration = -duration
+	}
+	return duration, nil
+}
+
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
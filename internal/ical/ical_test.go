@@ -0,0 +1,132 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Daily standup\, team A
+DTSTART;TZID=Europe/Berlin:20261019T093000
+DTEND;TZID=Europe/Berlin:20261019T094500
+RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+EXDATE;TZID=Europe/Berlin:20261021T093000
+BEGIN:VALARM
+ACTION:DISPLAY
+DESCRIPTION:Standup
+TRIGGER:-PT5M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID;TZID=Europe/Berlin:20261022T093000
+SUMMARY:Daily standup (moved)
+DTSTART;TZID=Europe/Berlin:20261022T110000
+DURATION:PT15M
+END:VEVENT
+BEGIN:VEVENT
+UID:offsite
+SUMMARY:Team offsite with a very long summary that is folded onto the next
+  line
+DTSTART;VALUE=DATE:20261020
+DTEND;VALUE=DATE:20261021
+TRANSP:TRANSPARENT
+END:VEVENT
+BEGIN:VEVENT
+UID:review
+SUMMARY:Review
+DTSTART:20261019T130000Z
+DTEND:20261019T140000Z
+STATUS:CANCELLED
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParse(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	events, err := Parse(strings.NewReader(strings.ReplaceAll(testCalendar, "\n", "\r\n")), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	standup := events[0]
+	assert.Equal(t, "Daily standup, team A", standup.Summary)
+	assert.Equal(t, time.Date(2026, 10, 19, 9, 30, 0, 0, loc), standup.Start)
+	assert.Equal(t, 15*time.Minute, standup.End.Sub(standup.Start))
+	assert.NotNil(t, standup.RRule)
+	assert.Equal(t, []time.Time{time.Date(2026, 10, 21, 9, 30, 0, 0, loc)}, standup.ExDates)
+
+	moved := events[1]
+	assert.Equal(t, time.Date(2026, 10, 22, 9, 30, 0, 0, loc), moved.RecurrenceId)
+	assert.Equal(t, time.Date(2026, 10, 22, 11, 15, 0, 0, loc), moved.End)
+
+	offsite := events[2]
+	assert.Equal(t, "Team offsite with a very long summary that is folded onto the next line", offsite.Summary)
+	assert.True(t, offsite.AllDay)
+	assert.True(t, offsite.Free)
+	assert.Equal(t, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), offsite.Start)
+
+	assert.True(t, events[3].Cancelled)
+}
+
+func TestExpand(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	events, err := Parse(strings.NewReader(testCalendar), loc)
+	require.NoError(t, err)
+
+	instances := Expand(events, time.Date(2026, 10, 19, 9, 40, 0, 0, loc), time.Date(2026, 10, 24, 0, 0, 0, 0, loc))
+
+	var starts []time.Time
+	for _, instance := range instances {
+		starts = append(starts, instance.Start)
+	}
+	assert.Equal(t, []time.Time{
+		// The first standup is still running.
+		time.Date(2026, 10, 19, 9, 30, 0, 0, loc),
+		time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 20, 0, 0, 0, 0, loc),
+		time.Date(2026, 10, 20, 9, 30, 0, 0, loc),
+		time.Date(2026, 10, 22, 11, 0, 0, 0, loc),
+		time.Date(2026, 10, 23, 9, 30, 0, 0, loc),
+	}, starts)
+	assert.Equal(t, "Daily standup (moved)", instances[4].Event.Summary)
+	assert.Equal(t, time.Date(2026, 10, 23, 9, 45, 0, 0, loc), instances[5].End)
+}
+
+func TestParseInvalidEvents(t *testing.T) {
+	const valid = "BEGIN:VEVENT\nSUMMARY:Valid\nDTSTART:20261019T120000Z\nEND:VEVENT\n"
+
+	// Invalid events are skipped without hiding the others.
+	for _, ics := range []string{
+		"BEGIN:VEVENT\nSUMMARY:No start\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nDTSTART:20261019T100000Z\nDURATION:1h\nEND:VEVENT\n",
+	} {
+		events, err := Parse(strings.NewReader(ics+valid), time.UTC)
+		require.NoError(t, err, ics)
+		require.Len(t, events, 1, ics)
+		assert.Equal(t, "Valid", events[0].Summary)
+	}
+
+	// Events with an unsupported recurrence rule only occur at their start.
+	events, err := Parse(strings.NewReader("BEGIN:VEVENT\nSUMMARY:Last workday\nDTSTART:20261030T100000Z\nRRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1\nEND:VEVENT\n"+valid), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Nil(t, events[0].RRule)
+	assert.Equal(t, time.Date(2026, 10, 30, 10, 0, 0, 0, time.UTC), events[0].Start)
+}
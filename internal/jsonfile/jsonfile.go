@@ -0,0 +1,69 @@
+// Package jsonfile reads and writes the JSON files in which nsc keeps its
+// state. The daemon and several invocations of nsc may access the same file
+// at once.
+package jsonfile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Read decodes the file at path into v. A missing file leaves v as is.
+func Read(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Write replaces the file at path with v encoded as JSON. The file is
+// replaced atomically so a concurrent reader never sees a partial write, and
+// each write uses its own temporary file so concurrent writers do not
+// interfere.
+func Write(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Update reads the file at path into v, calls modify and writes v back while
+// holding a lock, so concurrent updates are not lost. Nothing is written if
+// modify fails.
+func Update(path string, v any, modify func() error) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := Read(path, v); err != nil {
+		return err
+	}
+	if err := modify(); err != nil {
+		return err
+	}
+
+	return Write(path, v)
+}
@@ -0,0 +1,45 @@
+package jsonfile
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	var missing []int
+	require.NoError(t, Read(path, &missing))
+	assert.Nil(t, missing)
+
+	// Concurrent updates are not lost.
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var values []int
+			assert.NoError(t, Update(path, &values, func() error {
+				values = append(values, i)
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+
+	var values []int
+	require.NoError(t, Read(path, &values))
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, values)
+
+	// Failed updates are not written.
+	assert.Error(t, Update(path, &values, func() error {
+		values = nil
+		return assert.AnError
+	}))
+	require.NoError(t, Read(path, &values))
+	assert.Len(t, values, 20)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package jsonfile
+
+// lock does nothing on platforms without flock.
+func lock(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package jsonfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive lock on a file next to path and returns a function
+// releasing it.
+func lock(path string) (func(), error) {
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,190 @@
+package meeting
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:planning
+SUMMARY:Planning
+DTSTART:20261019T090000Z
+DTEND:20261019T100000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:design
+SUMMARY:Design review
+DTSTART:20261019T093000Z
+DTEND:20261019T103000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:focus
+SUMMARY:Focus time
+DTSTART:20261019T120000Z
+DTEND:20261019T130000Z
+TRANSP:TRANSPARENT
+END:VEVENT
+BEGIN:VEVENT
+UID:lunch
+SUMMARY:Lunch
+DTSTART:20261019T130000Z
+DTEND:20261019T133000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:vacation
+SUMMARY:Vacation
+DTSTART;VALUE=DATE:20261019
+DTEND;VALUE=DATE:20261020
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+SUMMARY:Cancelled
+DTSTART:20261019T150000Z
+DTEND:20261019T160000Z
+STATUS:CANCELLED
+END:VEVENT
+END:VCALENDAR
+`
+
+var day = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+func writeCalendar(t *testing.T, ics string) string {
+	path := filepath.Join(t.TempDir(), "calendar.ics")
+	require.NoError(t, os.WriteFile(path, []byte(ics), 0600))
+	return path
+}
+
+func summaries(meetings []Meeting) []string {
+	var s []string
+	for _, m := range meetings {
+		s = append(s, m.Summary)
+	}
+	return s
+}
+
+func TestFilters(t *testing.T) {
+	source := Source{Name: "work", Location: writeCalendar(t, testCalendar), TimeZone: time.UTC}
+	events, err := source.Fetch(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Planning", "Design review", "Lunch"}, summaries(source.Meetings(events, day, day.AddDate(0, 0, 1))))
+
+	source.IncludeFree = true
+	source.IncludeAllDay = true
+	source.Ignore = regexp.MustCompile(`(?i)lunch`)
+	assert.Equal(t, []string{"Vacation", "Planning", "Design review", "Focus time"}, summaries(source.Meetings(events, day, day.AddDate(0, 0, 1))))
+}
+
+func TestUpcomingSkipsFailingCalendars(t *testing.T) {
+	work := Source{Name: "work", Location: writeCalendar(t, testCalendar), TimeZone: time.UTC}
+	missing := Source{Name: "missing", Location: filepath.Join(t.TempDir(), "missing.ics"), TimeZone: time.UTC}
+
+	meetings, err := Upcoming([]Source{missing, work}, day, day.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Planning", "Design review", "Lunch"}, summaries(meetings))
+
+	_, err = Upcoming([]Source{missing}, day, day.AddDate(0, 0, 1))
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	auth := &ocs.Auth{ServerBaseUrl: "https://cloud.example.com", User: "alice"}
+	sources, err := Load(config.Config{Calendars: []config.Calendar{
+		{Source: "https://cloud.example.com/remote.php/dav/calendars/alice/personal?export"},
+		{Name: "Team", Source: "webcal://example.org/team.ics", Ignore: "^Lunch$"},
+		{Source: "caldav:Personal"},
+	}}, calendar.Default(), auth)
+	require.NoError(t, err)
+
+	assert.Equal(t, auth, sources[0].Auth)
+	assert.Equal(t, "Team", sources[1].Name)
+	assert.Equal(t, "https://example.org/team.ics", sources[1].Location)
+	assert.Nil(t, sources[1].Auth)
+	assert.True(t, sources[1].Ignore.MatchString("Lunch"))
+	assert.Equal(t, auth, sources[2].Auth)
+
+	_, err = Load(config.Config{Calendars: []config.Calendar{{Name: "Empty"}}}, calendar.Default(), nil)
+	assert.Error(t, err)
+	_, err = Load(config.Config{Calendars: []config.Calendar{{Source: "a.ics", Ignore: "("}}}, calendar.Default(), nil)
+	assert.Error(t, err)
+	_, err = Load(config.Config{Calendars: []config.Calendar{{Source: "caldav"}}}, calendar.Default(), nil)
+	assert.Error(t, err)
+}
+
+func TestWatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	ics := testCalendar
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if user, password, _ := r.BasicAuth(); user != "alice" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if ics == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(ics))
+	}))
+	defer server.Close()
+
+	auth := &ocs.Auth{ServerBaseUrl: server.URL, User: "alice", Password: "secret"}
+	sources, err := Load(config.Config{Calendars: []config.Calendar{{Name: "work", Source: server.URL + "/work.ics"}}}, calendar.Default(), auth)
+	require.NoError(t, err)
+	sources[0].TimeZone = time.UTC
+
+	var applied []ocs.UserStatus
+	w := &Watcher{
+		Sources:         sources,
+		RefreshInterval: time.Hour,
+		Apply: func(status ocs.UserStatus) error {
+			applied = append(applied, status)
+			return nil
+		},
+	}
+
+	assert.NoError(w.Tick(day.Add(8 * time.Hour)))
+	assert.Empty(applied)
+
+	// Overlapping meetings are merged.
+	assert.NoError(w.Tick(day.Add(9 * time.Hour)))
+	assert.NoError(w.Tick(day.Add(10 * time.Hour)))
+	assert.Equal([]ocs.UserStatus{{
+		Status:  "dnd",
+		Icon:    "📅",
+		Message: "In a meeting: Planning",
+		ClearAt: day.Add(10*time.Hour + 30*time.Minute).Unix(),
+	}}, applied)
+
+	// The previous events are kept if the calendar can't be fetched.
+	mu.Lock()
+	ics = ""
+	mu.Unlock()
+	assert.Error(w.Tick(day.Add(13 * time.Hour)))
+	assert.Len(applied, 2)
+	assert.Equal("In a meeting: Lunch", applied[1].Message)
+}
+
+func TestStatusMessageLength(t *testing.T) {
+	status := Status(Meeting{Summary: strings.Repeat("ä", 100)}, day)
+	assert.Len(t, []rune(status.Message), 80)
+	assert.True(t, strings.HasSuffix(status.Message, "…"))
+}
@@ -0,0 +1,247 @@
+// Package meeting sets a do not disturb status while events of calendars are
+// happening.
+package meeting
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/caldav"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/ical"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+// requestTimeout limits requests to calendar servers.
+const requestTimeout = 30 * time.Second
+
+// Source is a calendar in iCalendar format.
+type Source struct {
+	Name string
+
+	// Location is the path or http(s) URL of the calendar. It is "caldav" for
+	// all calendars of the user on the Nextcloud server or "caldav:<name>"
+	// for a single one.
+	Location string
+
+	// Auth is used for URLs on the Nextcloud server and CalDAV.
+	Auth *ocs.Auth
+
+	// TimeZone is used for floating times.
+	TimeZone *time.Location
+
+	IncludeFree   bool
+	IncludeAllDay bool
+
+	// Ignore matches the summaries of events to ignore.
+	Ignore *regexp.Regexp
+}
+
+// Meeting is an occurrence of an event that blocks time.
+type Meeting struct {
+	Calendar string
+	UID      string
+	Summary  string
+	Start    time.Time
+	End      time.Time
+}
+
+// Load returns the calendars of the config. The credentials are sent to
+// calendars on the Nextcloud server of auth.
+func Load(cfg config.Config, cal calendar.Calendar, auth *ocs.Auth) ([]Source, error) {
+	var sources []Source
+	for _, c := range cfg.Calendars {
+		if c.Source == "" {
+			return nil, fmt.Errorf("Calendar %q has no source", c.Name)
+		}
+
+		name := c.Name
+		if name == "" {
+			name = c.Source
+		}
+
+		source := Source{
+			Name:          name,
+			Location:      c.Source,
+			TimeZone:      cal.Location,
+			IncludeFree:   c.IncludeFree,
+			IncludeAllDay: c.IncludeAllDay,
+		}
+
+		if strings.HasPrefix(source.Location, "webcal://") {
+			source.Location = "https://" + strings.TrimPrefix(source.Location, "webcal://")
+		}
+
+		if source.isCalDAV() {
+			if auth == nil {
+				return nil, fmt.Errorf("Calendar %s requires you to log in first", name)
+			}
+			source.Auth = auth
+		} else if auth != nil && auth.ServerBaseUrl != "" && strings.HasPrefix(source.Location, strings.TrimRight(auth.ServerBaseUrl, "/")+"/") {
+			source.Auth = auth
+		}
+
+		if c.Ignore != "" {
+			ignore, err := regexp.Compile(c.Ignore)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid ignore pattern of calendar %s: %s", name, err)
+			}
+			source.Ignore = ignore
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+func (s Source) isCalDAV() bool {
+	return s.Location == "caldav" || strings.HasPrefix(s.Location, "caldav:")
+}
+
+func (s Source) isUrl() bool {
+	return strings.HasPrefix(s.Location, "http://") || strings.HasPrefix(s.Location, "https://")
+}
+
+// Fetch reads the events of the calendar. Calendars that support queries
+// only return the events that overlap [from, to).
+func (s Source) Fetch(from, to time.Time) ([]ical.Event, error) {
+	if s.isCalDAV() {
+		return s.fetchCalDAV(from, to)
+	}
+
+	var body io.ReadCloser
+	if s.isUrl() {
+		req, err := http.NewRequest("GET", s.Location, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Accept", "text/calendar")
+		if s.Auth != nil {
+			req.SetBasicAuth(s.Auth.User, s.Auth.Password)
+		}
+
+		res, err := ocs.NewHTTPClient(requestTimeout).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to fetch calendar %s: %s", s.Name, err)
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, fmt.Errorf("Failed to fetch calendar %s: %s", s.Name, res.Status)
+		}
+		body = res.Body
+	} else {
+		file, err := os.Open(s.Location)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read calendar %s: %s", s.Name, err)
+		}
+		body = file
+	}
+	defer body.Close()
+
+	events, err := ical.Parse(body, s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse calendar %s: %s", s.Name, err)
+	}
+
+	return events, nil
+}
+
+func (s Source) fetchCalDAV(from, to time.Time) ([]ical.Event, error) {
+	client := caldav.Client{Auth: *s.Auth}
+	calendars, err := client.Calendars()
+	if err != nil {
+		return nil, err
+	}
+
+	_, name, filtered := strings.Cut(s.Location, ":")
+	var events []ical.Event
+	found := false
+	for _, cal := range calendars {
+		if filtered && !strings.EqualFold(cal.Name, name) {
+			continue
+		}
+		found = true
+
+		calendarEvents, err := client.Events(cal, from, to, s.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, calendarEvents...)
+	}
+
+	if filtered && !found {
+		return nil, fmt.Errorf("No calendar named %s", name)
+	}
+
+	return events, nil
+}
+
+// Meetings returns the occurrences of the events that overlap [from, to) and
+// pass the filters of the calendar.
+func (s Source) Meetings(events []ical.Event, from, to time.Time) []Meeting {
+	var meetings []Meeting
+	for _, instance := range ical.Expand(events, from, to) {
+		event := instance.Event
+		switch {
+		case event.Cancelled:
+		case event.Free && !s.IncludeFree:
+		case event.AllDay && !s.IncludeAllDay:
+		case s.Ignore != nil && s.Ignore.MatchString(event.Summary):
+		case !instance.Start.Before(instance.End):
+		default:
+			meetings = append(meetings, Meeting{
+				Calendar: s.Name,
+				UID:      event.UID,
+				Summary:  event.Summary,
+				Start:    instance.Start,
+				End:      instance.End,
+			})
+		}
+	}
+
+	return meetings
+}
+
+// Upcoming fetches the calendars and returns their meetings that overlap
+// [from, to) sorted by their start. Calendars that fail to load are skipped,
+// so it only fails if all of them do.
+func Upcoming(sources []Source, from, to time.Time) ([]Meeting, error) {
+	var meetings []Meeting
+	var errs []error
+	for _, source := range sources {
+		events, err := source.Fetch(from, to)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		meetings = append(meetings, source.Meetings(events, from, to)...)
+	}
+
+	if len(errs) > 0 && len(errs) == len(sources) {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Printf("Skipping calendar: %s", err)
+	}
+
+	sortMeetings(meetings)
+	return meetings, nil
+}
+
+func sortMeetings(meetings []Meeting) {
+	slices.SortStableFunc(meetings, func(a, b Meeting) int {
+		return a.Start.Compare(b.Start)
+	})
+}
@@ -0,0 +1,129 @@
+package meeting
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ical"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+const (
+	Icon = "📅"
+
+	// maxMessageLength is the maximum length of status messages accepted by
+	// Nextcloud.
+	maxMessageLength = 80
+
+	// lookahead limits how far overlapping meetings are merged.
+	lookahead = 24 * time.Hour
+)
+
+// Status returns the status that is set during the given meeting until end.
+func Status(m Meeting, end time.Time) ocs.UserStatus {
+	message := "In a meeting"
+	if m.Summary != "" {
+		message += ": " + m.Summary
+	}
+	if runes := []rune(message); len(runes) > maxMessageLength {
+		message = string(runes[:maxMessageLength-1]) + "…"
+	}
+
+	return ocs.UserStatus{
+		Status:  "dnd",
+		Icon:    Icon,
+		Message: message,
+		ClearAt: end.Unix(),
+	}
+}
+
+// Watcher sets a meeting status when a meeting starts. It is driven by the
+// caller's clock to stay deterministic.
+type Watcher struct {
+	Sources []Source
+
+	// RefreshInterval is the interval in which the calendars are fetched
+	// again.
+	RefreshInterval time.Duration
+
+	// Apply sets the status of a meeting. It is cleared at the end of the
+	// meeting and the previous status should be restored then.
+	Apply func(status ocs.UserStatus) error
+
+	events    [][]ical.Event
+	fetchedAt time.Time
+
+	// until is the end of the meetings whose status was applied.
+	until time.Time
+}
+
+// Tick fetches the calendars if they are due and applies the status of the
+// current meeting unless it was already applied. Overlapping meetings are
+// merged into one, so the status of the first one is kept until all of them
+// end. Calendars that fail to load keep their previous events.
+func (w *Watcher) Tick(now time.Time) error {
+	var errs []error
+	if w.fetchedAt.IsZero() || now.Sub(w.fetchedAt) >= w.RefreshInterval {
+		errs = append(errs, w.fetch(now))
+		w.fetchedAt = now
+	}
+
+	meeting, end, ok := w.current(now)
+	if !ok || now.Before(w.until) {
+		return errors.Join(errs...)
+	}
+
+	if err := w.Apply(Status(meeting, end)); err != nil {
+		errs = append(errs, fmt.Errorf("Failed to set meeting status: %s", err))
+	} else {
+		w.until = end
+	}
+
+	return errors.Join(errs...)
+}
+
+// fetch reads the events that may happen until the next fetch.
+func (w *Watcher) fetch(now time.Time) error {
+	if len(w.events) != len(w.Sources) {
+		w.events = make([][]ical.Event, len(w.Sources))
+	}
+
+	var errs []error
+	for i, source := range w.Sources {
+		events, err := source.Fetch(now, now.Add(w.RefreshInterval+lookahead))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		w.events[i] = events
+	}
+
+	return errors.Join(errs...)
+}
+
+// current returns the earliest meeting that is happening now and the end of
+// the meetings overlapping with it.
+func (w *Watcher) current(now time.Time) (Meeting, time.Time, bool) {
+	var meetings []Meeting
+	for i, source := range w.Sources {
+		meetings = append(meetings, source.Meetings(w.events[i], now, now.Add(lookahead))...)
+	}
+	sortMeetings(meetings)
+
+	if len(meetings) == 0 || meetings[0].Start.After(now) {
+		return Meeting{}, time.Time{}, false
+	}
+
+	end := meetings[0].End
+	for _, m := range meetings[1:] {
+		if !m.Start.Before(end) {
+			break
+		}
+		if m.End.After(end) {
+			end = m.End
+		}
+	}
+
+	return meetings[0], end, true
+}
@@ -0,0 +1,186 @@
+package ocs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const capabilitiesEndpoint string = "/ocs/v2.php/cloud/capabilities?format=json"
+
+func outOfOfficeEndpoint(user string) string {
+	return fmt.Sprintf("/ocs/v2.php/apps/dav/api/v1/outOfOffice/%s?format=json", user)
+}
+
+// OutOfOffice is an absence of the user. The days are formatted as
+// "2006-01-02" and include the last day.
+type OutOfOffice struct {
+	UserId   string `json:"userId,omitempty"`
+	FirstDay string `json:"firstDay"`
+	LastDay  string `json:"lastDay"`
+
+	// Status is the short message shown as the user status during the
+	// absence.
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// SupportsOutOfOffice reports whether the server has the out-of-office API.
+func SupportsOutOfOffice(auth Auth) (bool, error) {
+	req, err := http.NewRequest("GET", auth.Endpoint(capabilitiesEndpoint), nil)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OCS-APIRequest", "true")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(auth.User, auth.Password)
+
+	client := NewHTTPClient(requestTimeout)
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return false, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("Failed to get capabilities: %s %s", res.Status, string(resBody))
+	}
+
+	var ocsResponse struct {
+		Ocs struct {
+			Data struct {
+				Capabilities struct {
+					Dav struct {
+						AbsenceSupported bool `json:"absence-supported"`
+					} `json:"dav"`
+				} `json:"capabilities"`
+			} `json:"data"`
+		} `json:"ocs"`
+	}
+	err = json.Unmarshal(resBody, &ocsResponse)
+	if err != nil {
+		return false, err
+	}
+
+	return ocsResponse.Ocs.Data.Capabilities.Dav.AbsenceSupported, nil
+}
+
+// GetOutOfOffice returns the absence of the user or nil if there is none.
+func GetOutOfOffice(auth Auth) (*OutOfOffice, error) {
+	req, err := http.NewRequest("GET", auth.Endpoint(outOfOfficeEndpoint(auth.User)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OCS-APIRequest", "true")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(auth.User, auth.Password)
+
+	client := NewHTTPClient(requestTimeout)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to get out-of-office: %s %s", res.Status, string(resBody))
+	}
+
+	var ocsResponse struct {
+		Ocs struct {
+			Data OutOfOffice `json:"data"`
+		} `json:"ocs"`
+	}
+	err = json.Unmarshal(resBody, &ocsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ocsResponse.Ocs.Data, nil
+}
+
+// SetOutOfOffice creates or replaces the absence of the user.
+func SetOutOfOffice(auth Auth, ooo OutOfOffice) error {
+	oooJson, err := json.Marshal(ooo)
+	if err != nil {
+		return err
+	}
+	body := bytes.NewBuffer(oooJson)
+	req, err := http.NewRequest("POST", auth.Endpoint(outOfOfficeEndpoint(auth.User)), body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("OCS-APIRequest", "true")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(auth.User, auth.Password)
+
+	client := NewHTTPClient(requestTimeout)
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("Failed to set out-of-office: %s %s", res.Status, string(resBody))
+}
+
+// ClearOutOfOffice removes the absence of the user.
+func ClearOutOfOffice(auth Auth) error {
+	req, err := http.NewRequest("DELETE", auth.Endpoint(outOfOfficeEndpoint(auth.User)), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OCS-APIRequest", "true")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(auth.User, auth.Password)
+
+	client := NewHTTPClient(requestTimeout)
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("Failed to clear out-of-office: %s %s", res.Status, string(resBody))
+}
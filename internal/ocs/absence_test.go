@@ -0,0 +1,65 @@
+package ocs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutOfOffice(t *testing.T) {
+	var stored *OutOfOffice
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/ocs/v2.php/cloud/capabilities":
+			w.Write([]byte(`{"ocs": {"data": {"capabilities": {"dav": {"absence-supported": true}}}}}`))
+		case r.URL.Path != "/ocs/v2.php/apps/dav/api/v1/outOfOffice/alice" || r.Header.Get("OCS-APIRequest") != "true":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST":
+			stored = &OutOfOffice{}
+			json.NewDecoder(r.Body).Decode(stored)
+			stored.UserId = "alice"
+		case r.Method == "DELETE":
+			stored = nil
+		case stored == nil:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"ocs": map[string]any{"data": stored}})
+		}
+	}))
+	defer server.Close()
+
+	auth := Auth{ServerBaseUrl: server.URL, User: "alice"}
+
+	supported, err := SupportsOutOfOffice(auth)
+	require.NoError(t, err)
+	assert.True(t, supported)
+
+	ooo, err := GetOutOfOffice(auth)
+	require.NoError(t, err)
+	assert.Nil(t, ooo)
+
+	require.NoError(t, SetOutOfOffice(auth, OutOfOffice{FirstDay: "2026-12-22", LastDay: "2027-01-02", Status: "Vacation", Message: "Back next year"}))
+	ooo, err = GetOutOfOffice(auth)
+	require.NoError(t, err)
+	assert.Equal(t, &OutOfOffice{UserId: "alice", FirstDay: "2026-12-22", LastDay: "2027-01-02", Status: "Vacation", Message: "Back next year"}, ooo)
+
+	require.NoError(t, ClearOutOfOffice(auth))
+	ooo, err = GetOutOfOffice(auth)
+	require.NoError(t, err)
+	assert.Nil(t, ooo)
+}
+
+func TestOutOfOfficeUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ocs": {"data": {"capabilities": {"user_status": {"enabled": true}}}}}`))
+	}))
+	defer server.Close()
+
+	supported, err := SupportsOutOfOffice(Auth{ServerBaseUrl: server.URL, User: "alice"})
+	require.NoError(t, err)
+	assert.False(t, supported)
+}
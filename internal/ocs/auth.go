@@ -3,18 +3,66 @@ package ocs
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+	"regexp"
 
 	"github.com/adrg/xdg"
 )
 
+// ProfileEnv is the environment variable that selects the profile to use.
+// Each profile has its own credentials. The default profile is used if it is
+// empty.
+const ProfileEnv = "NSC_PROFILE"
+
 type Auth struct {
 	ServerBaseUrl string `json:"serverBaseUrl"`
 	User          string `json:"user"`
 	Password      string `json:"password"`
 }
 
+// profilePattern restricts the names of profiles, which are used in paths and
+// in the names of systemd units.
+var profilePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func Profile() string {
+	return os.Getenv(ProfileEnv)
+}
+
+// ValidProfile reports whether name may be used as the name of a profile. It
+// may only contain letters, digits, dashes and underscores.
+func ValidProfile(name string) bool {
+	return profilePattern.MatchString(name)
+}
+
+// Profiles returns the names of the profiles with saved credentials besides
+// the default profile.
+func Profiles() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(xdg.ConfigHome, "nsc", "profiles"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	return profiles, nil
+}
+
+func authPath() (string, error) {
+	if profile := Profile(); profile != "" {
+		return xdg.ConfigFile(filepath.Join("nsc", "profiles", profile, "auth.json"))
+	}
+
+	return xdg.ConfigFile("nsc/auth.json")
+}
+
 func LoadAuth() (Auth, error) {
-	authPath, err := xdg.ConfigFile("nsc/auth.json")
+	authPath, err := authPath()
 	if err != nil {
 		return Auth{}, err
 	}
@@ -34,7 +82,7 @@ func LoadAuth() (Auth, error) {
 }
 
 func SaveAuth(auth Auth) error {
-	authPath, err := xdg.ConfigFile("nsc/auth.json")
+	authPath, err := authPath()
 	if err != nil {
 		return err
 	}
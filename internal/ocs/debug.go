@@ -0,0 +1,38 @@
+package ocs
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Debug logs every request to the server when it is set.
+var Debug bool
+
+// requestTimeout limits requests to the server, so a connection that stopped
+// responding does not block the daemon forever.
+const requestTimeout = 30 * time.Second
+
+// NewHTTPClient returns a client for requests to the server. A timeout of 0
+// means no timeout.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if Debug {
+		client.Transport = debugTransport{}
+	}
+	return client
+}
+
+type debugTransport struct{}
+
+func (debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		log.Printf("%s %s: %s", req.Method, req.URL.Redacted(), err)
+		return nil, err
+	}
+
+	log.Printf("%s %s: %s (%s)", req.Method, req.URL.Redacted(), res.Status, time.Since(start).Round(time.Millisecond))
+	return res, nil
+}
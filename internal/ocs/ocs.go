@@ -3,21 +3,29 @@ package ocs
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 )
 
 const userAgent string = "nextcloud-status-command/0.1.0"
 
+const userStatusEndpoint string = "/ocs/v2.php/apps/user_status/api/v1/user_status"
 const statusEndpoint string = "/ocs/v2.php/apps/user_status/api/v1/user_status/status"
 const messageEndpoint string = "/ocs/v2.php/apps/user_status/api/v1/user_status/message?format=json"
 const customMessageEndpoint string = "/ocs/v2.php/apps/user_status/api/v1/user_status/message/custom?format=json"
+const statusesEndpoint string = "/ocs/v2.php/apps/user_status/api/v1/statuses?format=json"
+const heartbeatEndpoint string = "/ocs/v2.php/apps/user_status/api/v1/heartbeat?format=json"
 
 func getStatusEndpoint(user string) string {
 	return fmt.Sprintf("/ocs/v2.php/apps/user_status/api/v1/statuses/%s", user)
 }
 
+// Statuses are the statuses a user can set.
+var Statuses = []string{"online", "away", "dnd", "invisible"}
+
 type StatusMessage struct {
 	ClearAt    int64  `json:"clearAt,omitempty"`
 	Message    string `json:"message"`
@@ -28,20 +36,71 @@ type Status struct {
 	StatusType string `json:"statusType"`
 }
 
+type heartbeat struct {
+	Status string `json:"status"`
+}
+
 type UserStatus struct {
-	User    string
-	Status  string
-	Icon    string
-	Message string
-	ClearAt int64
+	User    string `json:"user"`
+	Status  string `json:"status"`
+	Icon    string `json:"icon"`
+	Message string `json:"message"`
+	ClearAt int64  `json:"clearAt"`
+
+	// MessageId is the id of a predefined message. It is only set by the
+	// server.
+	MessageId           string `json:"messageId,omitempty"`
+	MessageIsPredefined bool   `json:"messageIsPredefined,omitempty"`
+}
+
+// SameStatus reports whether the current status is the one that was set. The
+// message of the set status may have been cleared by its timeout.
+func SameStatus(current, set UserStatus, now int64) bool {
+	if current.Status != set.Status {
+		return false
+	}
+
+	if current.Icon == set.Icon && current.Message == set.Message {
+		return true
+	}
+
+	expired := set.ClearAt > 0 && set.ClearAt <= now
+	return expired && current.Icon == "" && current.Message == ""
+}
+
+type ocsUserStatus struct {
+	UserId              string `json:"userId"`
+	Status              string `json:"status"`
+	Icon                string `json:"icon"`
+	Message             string `json:"message"`
+	ClearAt             int64  `json:"clearAt"`
+	MessageId           string `json:"messageId"`
+	MessageIsPredefined bool   `json:"messageIsPredefined"`
 }
 
 func (a *Auth) Endpoint(url string) string {
 	return fmt.Sprintf("%s%s", a.ServerBaseUrl, url)
 }
 
+// GetStatus returns the status of the authenticated user. Unlike the public
+// status of a user, it includes the id of a predefined message.
 func GetStatus(auth Auth) (*UserStatus, error) {
-	req, err := http.NewRequest("GET", auth.Endpoint(getStatusEndpoint(auth.User)), nil)
+	return getUserStatus(auth, userStatusEndpoint, auth.User)
+}
+
+// GetUserStatus returns the public status of another user. Users who never
+// set a status are reported as offline, as the server does in the web
+// interface.
+func GetUserStatus(auth Auth, user string) (*UserStatus, error) {
+	status, err := getUserStatus(auth, getStatusEndpoint(user), user)
+	if err == nil && status == nil {
+		status = &UserStatus{User: user, Status: "offline"}
+	}
+	return status, err
+}
+
+func getUserStatus(auth Auth, endpoint string, user string) (*UserStatus, error) {
+	req, err := http.NewRequest("GET", auth.Endpoint(endpoint), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +110,7 @@ func GetStatus(auth Auth) (*UserStatus, error) {
 	req.Header.Set("User-Agent", userAgent)
 	req.SetBasicAuth(auth.User, auth.Password)
 
-	client := &http.Client{}
+	client := NewHTTPClient(requestTimeout)
 	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -77,7 +136,7 @@ func GetStatus(auth Auth) (*UserStatus, error) {
 
 	data := ocsResponse["ocs"].(map[string]any)["data"].(map[string]any)
 	status := UserStatus{
-		User:   auth.User,
+		User:   user,
 		Status: data["status"].(string),
 	}
 
@@ -96,9 +155,73 @@ func GetStatus(auth Auth) (*UserStatus, error) {
 		status.ClearAt = int64(data["clearAt"].(float64))
 	}
 
+	switch data["messageId"].(type) {
+	case string:
+		status.MessageId = data["messageId"].(string)
+	}
+
+	switch data["messageIsPredefined"].(type) {
+	case bool:
+		status.MessageIsPredefined = data["messageIsPredefined"].(bool)
+	}
+
 	return &status, nil
 }
 
+func GetStatuses(auth Auth) ([]UserStatus, error) {
+	req, err := http.NewRequest("GET", auth.Endpoint(statusesEndpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OCS-APIRequest", "true")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(auth.User, auth.Password)
+
+	client := NewHTTPClient(requestTimeout)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to get statuses: %s %s", res.Status, string(resBody))
+	}
+
+	var ocsResponse struct {
+		Ocs struct {
+			Data []ocsUserStatus `json:"data"`
+		} `json:"ocs"`
+	}
+	err = json.Unmarshal(resBody, &ocsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]UserStatus, 0, len(ocsResponse.Ocs.Data))
+	for _, data := range ocsResponse.Ocs.Data {
+		statuses = append(statuses, UserStatus{
+			User:    data.UserId,
+			Status:  data.Status,
+			Icon:    data.Icon,
+			Message: data.Message,
+			ClearAt: data.ClearAt,
+
+			MessageId:           data.MessageId,
+			MessageIsPredefined: data.MessageIsPredefined,
+		})
+	}
+
+	return statuses, nil
+}
+
 func UpdateStatus(auth Auth, status Status) error {
 	statusJson, err := json.Marshal(status)
 	if err != nil {
@@ -116,7 +239,7 @@ func UpdateStatus(auth Auth, status Status) error {
 	req.Header.Set("User-Agent", userAgent)
 	req.SetBasicAuth(auth.User, auth.Password)
 
-	client := &http.Client{}
+	client := NewHTTPClient(requestTimeout)
 	res, err := client.Do(req)
 	if err != nil {
 		return err
@@ -153,7 +276,7 @@ func UpdateStatusMessage(auth Auth, message StatusMessage) error {
 	req.Header.Set("User-Agent", userAgent)
 	req.SetBasicAuth(auth.User, auth.Password)
 
-	client := &http.Client{}
+	client := NewHTTPClient(requestTimeout)
 	res, err := client.Do(req)
 	if err != nil {
 		return err
@@ -173,6 +296,92 @@ func UpdateStatusMessage(auth Auth, message StatusMessage) error {
 	return fmt.Errorf("Failed to update status message: %s %s", res.Status, resBodyString)
 }
 
+// ApplyStatus sets the status type and the custom status message at once.
+func ApplyStatus(auth Auth, status UserStatus) error {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	var statusErr, messageErr error
+	go func() {
+		statusErr = UpdateStatus(auth, Status{
+			StatusType: status.Status,
+		})
+
+		wg.Done()
+	}()
+
+	go func() {
+		messageErr = UpdateStatusMessage(auth, StatusMessage{
+			ClearAt:    status.ClearAt,
+			Message:    status.Message,
+			StatusIcon: status.Icon,
+		})
+
+		wg.Done()
+	}()
+
+	wg.Wait()
+	return errors.Join(statusErr, messageErr)
+}
+
+// Heartbeat reports the user as active ("online") or inactive ("away"). The
+// server only changes automatically set statuses and returns the resulting
+// status, or nil if there is none.
+func Heartbeat(auth Auth, status string) (*UserStatus, error) {
+	heartbeatJson, err := json.Marshal(heartbeat{Status: status})
+	if err != nil {
+		return nil, err
+	}
+	body := bytes.NewBuffer(heartbeatJson)
+	req, err := http.NewRequest("PUT", auth.Endpoint(heartbeatEndpoint), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("OCS-APIRequest", "true")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(auth.User, auth.Password)
+
+	client := NewHTTPClient(requestTimeout)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNoContent {
+		return nil, nil
+	} else if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to send heartbeat: %s %s", res.Status, string(resBody))
+	}
+
+	var ocsResponse struct {
+		Ocs struct {
+			Data ocsUserStatus `json:"data"`
+		} `json:"ocs"`
+	}
+	err = json.Unmarshal(resBody, &ocsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	data := ocsResponse.Ocs.Data
+	return &UserStatus{
+		User:    data.UserId,
+		Status:  data.Status,
+		Icon:    data.Icon,
+		Message: data.Message,
+		ClearAt: data.ClearAt,
+	}, nil
+}
+
 func ClearStatusMessage(auth Auth) error {
 	req, err := http.NewRequest("DELETE", auth.Endpoint(messageEndpoint), nil)
 	if err != nil {
@@ -184,7 +393,7 @@ func ClearStatusMessage(auth Auth) error {
 	req.Header.Set("User-Agent", userAgent)
 	req.SetBasicAuth(auth.User, auth.Password)
 
-	client := &http.Client{}
+	client := NewHTTPClient(requestTimeout)
 	res, err := client.Do(req)
 	if err != nil {
 		return err
@@ -0,0 +1,52 @@
+package ocs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		// Only the status of the authenticated user includes the message id.
+		case "/ocs/v2.php/apps/user_status/api/v1/user_status":
+			w.Write([]byte(`{"ocs": {"data": {"userId": "alice", "status": "dnd", "icon": "📅", "message": "In a meeting", "clearAt": null, "messageId": "meeting", "messageIsPredefined": true}}}`))
+		case "/ocs/v2.php/apps/user_status/api/v1/statuses/bob":
+			w.Write([]byte(`{"ocs": {"data": {"userId": "bob", "status": "away", "icon": null, "message": null, "clearAt": 1760000000}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	auth := Auth{ServerBaseUrl: server.URL, User: "alice"}
+
+	status, err := GetStatus(auth)
+	require.NoError(t, err)
+	assert.Equal(t, &UserStatus{User: "alice", Status: "dnd", Icon: "📅", Message: "In a meeting", MessageId: "meeting", MessageIsPredefined: true}, status)
+
+	status, err = GetUserStatus(auth, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, &UserStatus{User: "bob", Status: "away", ClearAt: 1760000000}, status)
+
+	status, err = GetUserStatus(auth, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, &UserStatus{User: "carol", Status: "offline"}, status)
+}
+
+func TestSameStatus(t *testing.T) {
+	set := UserStatus{Status: "dnd", Icon: "🏗️", Message: "Running migrations", ClearAt: 100}
+
+	assert.True(t, SameStatus(set, set, 50))
+	assert.False(t, SameStatus(UserStatus{Status: "dnd", Icon: "🍔", Message: "Lunch"}, set, 50))
+	assert.False(t, SameStatus(UserStatus{Status: "dnd"}, set, 50))
+	assert.False(t, SameStatus(UserStatus{Status: "away", Icon: "🏗️", Message: "Running migrations"}, set, 50))
+
+	// The message was cleared by its timeout.
+	assert.True(t, SameStatus(UserStatus{Status: "dnd"}, set, 100))
+}
@@ -0,0 +1,58 @@
+package ocs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const predefinedStatusesEndpoint string = "/ocs/v2.php/apps/user_status/api/v1/predefined_statuses?format=json"
+
+// PredefinedStatus is a status message offered by the server, e.g. "In a
+// meeting".
+type PredefinedStatus struct {
+	Id      string `json:"id"`
+	Icon    string `json:"icon"`
+	Message string `json:"message"`
+}
+
+func GetPredefinedStatuses(auth Auth) ([]PredefinedStatus, error) {
+	req, err := http.NewRequest("GET", auth.Endpoint(predefinedStatusesEndpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OCS-APIRequest", "true")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(auth.User, auth.Password)
+
+	client := NewHTTPClient(requestTimeout)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to get predefined statuses: %s %s", res.Status, string(resBody))
+	}
+
+	var ocsResponse struct {
+		Ocs struct {
+			Data []PredefinedStatus `json:"data"`
+		} `json:"ocs"`
+	}
+	err = json.Unmarshal(resBody, &ocsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return ocsResponse.Ocs.Data, nil
+}
@@ -0,0 +1,28 @@
+package ocs
+
+import (
+	"errors"
+	"net/url"
+)
+
+// TransportError is a failed request that did not reach the server, e.g.
+// because the network is down.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransportError reports whether err was caused by a request that did not
+// reach the server. Errors returned by the server itself are not.
+func IsTransportError(err error) bool {
+	var transportErr *TransportError
+	var urlErr *url.Error
+	return errors.As(err, &transportErr) || errors.As(err, &urlErr)
+}
@@ -0,0 +1,166 @@
+// Package preset stores named statuses that can be applied with a single
+// word.
+package preset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/jsonfile"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+type Preset struct {
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+	Icon   string `json:"icon,omitempty"`
+
+	// Message is a Go template rendered with Vars when the preset is
+	// applied, e.g. "Focus until {{.Until}}".
+	Message string `json:"message,omitempty"`
+
+	// Timeout is an expression (e.g. "1 hour" or "17:30") resolved when the
+	// preset is applied.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Vars are the variables available in the message of a preset.
+type Vars struct {
+	// Until is the time at which the status is cleared, e.g. "15:30" or
+	// "Fri 17:00" if it is not today. It is empty without a timeout.
+	Until string
+	// Now is the current time, e.g. "13:30".
+	Now string
+	// Date is the current date, e.g. "2026-10-19".
+	Date string
+	// Weekday is the current day of the week, e.g. "Monday".
+	Weekday string
+}
+
+// Resolve returns the status of the preset applied at now.
+func (p Preset) Resolve(cal calendar.Calendar, now time.Time) (ocs.UserStatus, error) {
+	now = now.In(cal.Location)
+	status := ocs.UserStatus{Status: p.Status, Icon: p.Icon}
+	if status.Status == "" {
+		status.Status = "online"
+	}
+
+	var err error
+	if p.Timeout != "" {
+		status.ClearAt, err = cal.ParseTimeout(p.Timeout, now)
+		if err != nil {
+			return ocs.UserStatus{}, err
+		}
+	}
+
+	tmpl, err := template.New(p.Name).Option("missingkey=error").Parse(p.Message)
+	if err != nil {
+		return ocs.UserStatus{}, fmt.Errorf("Invalid message of preset %q: %s", p.Name, err)
+	}
+
+	vars := Vars{
+		Now:     now.Format("15:04"),
+		Date:    now.Format(time.DateOnly),
+		Weekday: now.Weekday().String(),
+	}
+	if status.ClearAt > 0 {
+		until := time.Unix(status.ClearAt, 0).In(cal.Location)
+		vars.Until = until.Format("15:04")
+		if cal.Day(until) != cal.Day(now) {
+			vars.Until = until.Format("Mon 15:04")
+		}
+	}
+
+	var message strings.Builder
+	if err := tmpl.Execute(&message, vars); err != nil {
+		return ocs.UserStatus{}, fmt.Errorf("Invalid message of preset %q: %s", p.Name, err)
+	}
+	status.Message = message.String()
+
+	return status, nil
+}
+
+// Store keeps the presets in a JSON file.
+type Store struct {
+	Path string
+}
+
+// DefaultStore returns the store in the XDG config directory. Presets are
+// shared by all profiles.
+func DefaultStore() (Store, error) {
+	path, err := xdg.ConfigFile("nsc/presets.json")
+	if err != nil {
+		return Store{}, err
+	}
+
+	return Store{Path: path}, nil
+}
+
+// List returns the presets in the order they were added.
+func (s Store) List() ([]Preset, error) {
+	var presets []Preset
+	err := jsonfile.Read(s.Path, &presets)
+	return presets, err
+}
+
+// update loads the presets, modifies them and saves them again while holding
+// a lock.
+func (s Store) update(modify func(presets *[]Preset) error) error {
+	var presets []Preset
+	return jsonfile.Update(s.Path, &presets, func() error {
+		return modify(&presets)
+	})
+}
+
+func (s Store) Get(name string) (Preset, error) {
+	presets, err := s.List()
+	if err != nil {
+		return Preset{}, err
+	}
+
+	i := slices.IndexFunc(presets, func(preset Preset) bool {
+		return preset.Name == name
+	})
+	if i < 0 {
+		return Preset{}, fmt.Errorf("No preset named %q", name)
+	}
+
+	return presets[i], nil
+}
+
+// Add saves a new preset. An existing preset with the same name is only
+// replaced if replace is set.
+func (s Store) Add(preset Preset, replace bool) error {
+	return s.update(func(presets *[]Preset) error {
+		i := slices.IndexFunc(*presets, func(p Preset) bool {
+			return p.Name == preset.Name
+		})
+		if i >= 0 && !replace {
+			return fmt.Errorf("Preset %q already exists", preset.Name)
+		} else if i >= 0 {
+			(*presets)[i] = preset
+		} else {
+			*presets = append(*presets, preset)
+		}
+		return nil
+	})
+}
+
+func (s Store) Remove(name string) error {
+	return s.update(func(presets *[]Preset) error {
+		i := slices.IndexFunc(*presets, func(preset Preset) bool {
+			return preset.Name == name
+		})
+		if i < 0 {
+			return fmt.Errorf("No preset named %q", name)
+		}
+
+		*presets = slices.Delete(*presets, i, i+1)
+		return nil
+	})
+}
@@ -0,0 +1,69 @@
+package preset
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddListRemove(t *testing.T) {
+	assert := assert.New(t)
+	store := Store{Path: filepath.Join(t.TempDir(), "presets.json")}
+
+	presets, err := store.List()
+	assert.NoError(err)
+	assert.Empty(presets)
+
+	focus := Preset{Name: "focus", Status: "dnd", Icon: "🎧", Message: "Focus", Timeout: "1 hour"}
+	lunch := Preset{Name: "lunch", Status: "away", Icon: "🍔", Message: "Lunch"}
+	require.NoError(t, store.Add(focus, false))
+	require.NoError(t, store.Add(lunch, false))
+
+	presets, err = store.List()
+	assert.NoError(err)
+	assert.Equal([]Preset{focus, lunch}, presets)
+
+	// Existing presets are only replaced on request.
+	focus.Timeout = "2 hours"
+	assert.Error(store.Add(focus, false))
+	assert.NoError(store.Add(focus, true))
+
+	preset, err := store.Get("focus")
+	assert.NoError(err)
+	assert.Equal("2 hours", preset.Timeout)
+
+	assert.NoError(store.Remove("focus"))
+	assert.Error(store.Remove("focus"))
+	_, err = store.Get("focus")
+	assert.Error(err)
+
+	presets, err = store.List()
+	assert.NoError(err)
+	assert.Equal([]Preset{lunch}, presets)
+}
+
+func TestResolve(t *testing.T) {
+	assert := assert.New(t)
+	cal := calendar.Default()
+	cal.Location = time.UTC
+	now := time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC)
+
+	status, err := Preset{Name: "focus", Status: "dnd", Icon: "🎧", Message: "Focus until {{.Until}}", Timeout: "90m"}.Resolve(cal, now)
+	assert.NoError(err)
+	assert.Equal(ocs.UserStatus{Status: "dnd", Icon: "🎧", Message: "Focus until 15:00", ClearAt: now.Add(90 * time.Minute).Unix()}, status)
+
+	status, err = Preset{Name: "trip", Message: "Back {{.Until}}, left on {{.Weekday}}", Timeout: "friday 17:00"}.Resolve(cal, now)
+	assert.NoError(err)
+	assert.Equal("online", status.Status)
+	assert.Equal("Back Fri 17:00, left on Monday", status.Message)
+
+	_, err = Preset{Name: "typo", Message: "{{.Unitl}}"}.Resolve(cal, now)
+	assert.Error(err)
+	_, err = Preset{Name: "past", Timeout: "2026-10-01"}.Resolve(cal, now)
+	assert.Error(err)
+}
@@ -0,0 +1,213 @@
+// Package queue keeps status changes that failed because the server was not
+// reachable, so they can be applied once it is again.
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/jsonfile"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+type Entry struct {
+	Id       string `json:"id"`
+	QueuedAt int64  `json:"queuedAt"`
+
+	// Clear is set if the status message is cleared instead of setting
+	// Status.
+	Clear  bool           `json:"clear,omitempty"`
+	Status ocs.UserStatus `json:"status"`
+}
+
+type state struct {
+	NextId  int     `json:"nextId"`
+	Entries []Entry `json:"entries"`
+}
+
+// Store keeps the queued status changes in a JSON file.
+type Store struct {
+	Path string
+}
+
+// DefaultStore returns the store of the current profile in the XDG state
+// directory.
+func DefaultStore() (Store, error) {
+	name := "nsc/queue.json"
+	if profile := ocs.Profile(); profile != "" {
+		name = filepath.Join("nsc", "profiles", profile, "queue.json")
+	}
+
+	path, err := xdg.StateFile(name)
+	if err != nil {
+		return Store{}, err
+	}
+
+	return Store{Path: path}, nil
+}
+
+func (s Store) load() (state, error) {
+	st := state{NextId: 1}
+	err := jsonfile.Read(s.Path, &st)
+	return st, err
+}
+
+// update loads the state, modifies it and saves it again while holding a
+// lock, as the daemon and nsc may flush the queue at the same time.
+func (s Store) update(modify func(st *state) error) error {
+	st := state{NextId: 1}
+	return jsonfile.Update(s.Path, &st, func() error {
+		return modify(&st)
+	})
+}
+
+// List returns all queued status changes in the order they were queued.
+func (s Store) List() ([]Entry, error) {
+	st, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return st.Entries, nil
+}
+
+// Add queues a status change and returns it with its id. If clear is set,
+// the status message is cleared instead of setting status.
+func (s Store) Add(queuedAt time.Time, clear bool, status ocs.UserStatus) (Entry, error) {
+	var entry Entry
+	err := s.update(func(st *state) error {
+		entry = Entry{
+			Id:       strconv.Itoa(st.NextId),
+			QueuedAt: queuedAt.Unix(),
+			Clear:    clear,
+			Status:   status,
+		}
+		st.NextId++
+		st.Entries = append(st.Entries, entry)
+		return nil
+	})
+
+	return entry, err
+}
+
+func (s Store) Drop(id string) error {
+	return s.update(func(st *state) error {
+		i := slices.IndexFunc(st.Entries, func(entry Entry) bool {
+			return entry.Id == id
+		})
+		if i < 0 {
+			return fmt.Errorf("No queued status change with id %s", id)
+		}
+
+		st.Entries = slices.Delete(st.Entries, i, i+1)
+		return nil
+	})
+}
+
+func (s Store) DropAll() error {
+	return s.update(func(st *state) error {
+		st.Entries = nil
+		return nil
+	})
+}
+
+// take removes all queued status changes.
+func (s Store) take() ([]Entry, error) {
+	// Most of the time nothing is queued, so the lock is only taken if
+	// there is something to take.
+	if st, err := s.load(); err != nil || len(st.Entries) == 0 {
+		return nil, err
+	}
+
+	var entries []Entry
+	err := s.update(func(st *state) error {
+		entries = st.Entries
+		st.Entries = nil
+		return nil
+	})
+
+	return entries, err
+}
+
+// putBack restores status changes that could not be applied in front of the
+// ones queued in the meantime.
+func (s Store) putBack(entries []Entry) error {
+	return s.update(func(st *state) error {
+		st.Entries = append(slices.Clip(entries), st.Entries...)
+		return nil
+	})
+}
+
+// Runner applies queued status changes.
+type Runner struct {
+	Store Store
+
+	// Apply sets a queued status.
+	Apply func(status ocs.UserStatus) error
+
+	// Clear clears the status message.
+	Clear func() error
+}
+
+// Flush applies the queued status changes in order and returns how many were
+// applied. It stops at the first change that fails because the server is
+// still not reachable and keeps the remaining ones. Changes rejected by the
+// server are dropped.
+func (r Runner) Flush(now time.Time) (int, error) {
+	entries, err := r.Store.take()
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	applied := 0
+	var errs []error
+	entries = pending(entries)
+	for i, entry := range entries {
+		err := r.apply(entry, now)
+		if ocs.IsTransportError(err) {
+			errs = append(errs, err, r.Store.putBack(entries[i:]))
+			break
+		} else if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		applied++
+	}
+
+	return applied, errors.Join(errs...)
+}
+
+// pending returns the changes that still matter. Every status set overrides
+// all changes queued before it.
+func pending(entries []Entry) []Entry {
+	for i, entry := range slices.Backward(entries) {
+		if !entry.Clear {
+			return entries[i:]
+		}
+	}
+	return entries
+}
+
+// apply applies a queued change. A status whose timeout has passed in the
+// meantime is set without its message, as the server would have cleared it
+// by now.
+func (r Runner) apply(entry Entry, now time.Time) error {
+	if entry.Clear {
+		return r.Clear()
+	}
+
+	status := entry.Status
+	if status.ClearAt > 0 && status.ClearAt <= now.Unix() {
+		status.Icon = ""
+		status.Message = ""
+		status.ClearAt = 0
+	}
+
+	return r.Apply(status)
+}
@@ -0,0 +1,138 @@
+package queue
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+
+var errOffline = &url.Error{Op: "Put", URL: "https://cloud.example.com", Err: errors.New("network is unreachable")}
+
+func newStore(t *testing.T) Store {
+	return Store{Path: filepath.Join(t.TempDir(), "queue.json")}
+}
+
+type recorder struct {
+	applied []string
+	err     error
+}
+
+func (r *recorder) apply(status ocs.UserStatus) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.applied = append(r.applied, status.Status+" "+status.Message)
+	return nil
+}
+
+func (r *recorder) clear() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.applied = append(r.applied, "clear")
+	return nil
+}
+
+func TestAddListDrop(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+
+	lunch, err := store.Add(start, false, ocs.UserStatus{Status: "away", Message: "Lunch"})
+	require.NoError(t, err)
+	clear, err := store.Add(start.Add(time.Minute), true, ocs.UserStatus{})
+	require.NoError(t, err)
+	assert.Equal("1", lunch.Id)
+	assert.Equal("2", clear.Id)
+
+	entries, err := store.List()
+	assert.NoError(err)
+	assert.Equal([]Entry{lunch, clear}, entries)
+
+	assert.NoError(store.Drop("1"))
+	assert.Error(store.Drop("1"))
+
+	entries, err = store.List()
+	assert.NoError(err)
+	assert.Equal([]Entry{clear}, entries)
+
+	assert.NoError(store.DropAll())
+	entries, err = store.List()
+	assert.NoError(err)
+	assert.Empty(entries)
+}
+
+func TestRunnerFlush(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+	rec := &recorder{}
+	runner := Runner{Store: store, Apply: rec.apply, Clear: rec.clear}
+
+	store.Add(start, false, ocs.UserStatus{Status: "away", Message: "Lunch"})
+	store.Add(start.Add(time.Minute), false, ocs.UserStatus{Status: "dnd", Message: "Meeting", ClearAt: start.Add(time.Hour).Unix()})
+	store.Add(start.Add(2*time.Minute), true, ocs.UserStatus{})
+
+	// The first status is overridden by the second one.
+	applied, err := runner.Flush(start.Add(5 * time.Minute))
+	assert.NoError(err)
+	assert.Equal(2, applied)
+	assert.Equal([]string{"dnd Meeting", "clear"}, rec.applied)
+
+	entries, err := store.List()
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	applied, err = runner.Flush(start.Add(10 * time.Minute))
+	assert.NoError(err)
+	assert.Equal(0, applied)
+}
+
+func TestRunnerFlushExpired(t *testing.T) {
+	store := newStore(t)
+	rec := &recorder{}
+	runner := Runner{Store: store, Apply: rec.apply, Clear: rec.clear}
+
+	store.Add(start, false, ocs.UserStatus{Status: "dnd", Message: "Meeting", ClearAt: start.Add(time.Hour).Unix()})
+
+	_, err := runner.Flush(start.Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dnd "}, rec.applied)
+}
+
+func TestRunnerFlushOffline(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+	rec := &recorder{err: errOffline}
+	runner := Runner{Store: store, Apply: rec.apply, Clear: rec.clear}
+
+	store.Add(start, false, ocs.UserStatus{Status: "away", Message: "Lunch"})
+
+	_, err := runner.Flush(start)
+	assert.True(ocs.IsTransportError(err))
+
+	// The change is kept and new ones are queued behind it.
+	store.Add(start.Add(time.Minute), true, ocs.UserStatus{})
+	entries, err := store.List()
+	assert.NoError(err)
+	require.Len(t, entries, 2)
+	assert.Equal("Lunch", entries[0].Status.Message)
+
+	// Changes rejected by the server are dropped.
+	rec.err = errors.New("Failed to update status: 400 Bad Request")
+	_, err = runner.Flush(start.Add(2 * time.Minute))
+	assert.Error(err)
+	assert.False(ocs.IsTransportError(err))
+
+	entries, err = store.List()
+	assert.NoError(err)
+	assert.Empty(entries)
+}
@@ -0,0 +1,180 @@
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	cronMacros = map[string]string{
+		"@hourly":   "0 * * * *",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@weekly":   "0 0 * * 0",
+		"@monthly":  "0 0 1 * *",
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+	}
+
+	cronMonths   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronWeekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// Cron is a standard five field cron expression. Day of month and day of
+// week match if either matches when both are restricted.
+type Cron struct {
+	Location *time.Location
+
+	minutes     []int
+	hours       []int
+	monthDays   []int
+	months      []int
+	weekdays    []int
+	anyMonthDay bool
+	anyWeekday  bool
+}
+
+func ParseCron(expr string, loc *time.Location) (Cron, error) {
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(strings.ToLower(expr))
+	if len(fields) != 5 {
+		return Cron{}, fmt.Errorf("Invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	c := Cron{
+		Location:    loc,
+		anyMonthDay: fields[2] == "*",
+		anyWeekday:  fields[4] == "*",
+	}
+
+	var err error
+	if c.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return Cron{}, err
+	}
+	if c.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return Cron{}, err
+	}
+	if c.monthDays, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return Cron{}, err
+	}
+	if c.months, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return Cron{}, err
+	}
+	if c.weekdays, err = parseCronField(fields[4], 0, 7, cronWeekdays); err != nil {
+		return Cron{}, err
+	}
+
+	// Both 0 and 7 are Sunday.
+	for i, weekday := range c.weekdays {
+		c.weekdays[i] = weekday % 7
+	}
+
+	return c, nil
+}
+
+// parseCronField parses lists of values, ranges and steps like "1,15",
+// "mon-fri" or "*/15". Names are matched to values starting at min.
+func parseCronField(field string, min, max int, names []string) ([]int, error) {
+	invalid := fmt.Errorf("Invalid cron field %q", field)
+
+	parseValue := func(value string) (int, error) {
+		if i := slices.Index(names, value); i >= 0 {
+			return min + i, nil
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < min || n > max {
+			return 0, invalid
+		}
+		return n, nil
+	}
+
+	var values []int
+	for _, part := range strings.Split(field, ",") {
+		valueRange, stepValue, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepValue)
+			if err != nil || step < 1 {
+				return nil, invalid
+			}
+		}
+
+		first, last := min, max
+		if valueRange != "*" {
+			from, to, isRange := strings.Cut(valueRange, "-")
+			var err error
+			if first, err = parseValue(from); err != nil {
+				return nil, err
+			}
+
+			last = first
+			if isRange {
+				if last, err = parseValue(to); err != nil || last < first {
+					return nil, invalid
+				}
+			} else if hasStep {
+				last = max
+			}
+		}
+
+		for value := first; value <= last; value += step {
+			values = append(values, value)
+		}
+	}
+
+	slices.Sort(values)
+	return slices.Compact(values), nil
+}
+
+func (c Cron) matchesDay(day time.Time) bool {
+	if !slices.Contains(c.months, int(day.Month())) {
+		return false
+	}
+
+	monthDay := slices.Contains(c.monthDays, day.Day())
+	weekday := slices.Contains(c.weekdays, int(day.Weekday()))
+	switch {
+	case c.anyMonthDay && c.anyWeekday:
+		return true
+	case c.anyMonthDay:
+		return weekday
+	case c.anyWeekday:
+		return monthDay
+	default:
+		return monthDay || weekday
+	}
+}
+
+func (c Cron) Next(after time.Time) time.Time {
+	after = after.In(c.Location)
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, c.Location)
+
+	// Every valid expression matches at least once within eight years
+	// (29th of February).
+	for i := 0; i < 8*366; i++ {
+		if c.matchesDay(day) {
+			for _, hour := range c.hours {
+				for _, minute := range c.minutes {
+					// Clock times skipped by daylight saving time do not
+					// occur.
+					t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, c.Location)
+					if t.After(after) && t.Hour() == hour {
+						return t
+					}
+				}
+			}
+		}
+
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}
+}
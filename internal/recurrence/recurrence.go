@@ -0,0 +1,84 @@
+// Package recurrence computes the occurrences of cron expressions and
+// iCalendar recurrence rules (RFC 5545).
+package recurrence
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Recurrence interface {
+	// Next returns the first occurrence after the given time or the zero
+	// time if there is none.
+	Next(after time.Time) time.Time
+}
+
+// defaultStart anchors recurrence rules without a DTSTART. It is a Monday at
+// midnight.
+var defaultStart = time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)
+
+// Parse parses a cron expression ("0 12 * * mon-fri") or a recurrence rule
+// ("FREQ=WEEKLY;BYDAY=FR;BYHOUR=16"), optionally preceded by a DTSTART line.
+// Times are interpreted in the given location.
+func Parse(expr string, loc *time.Location) (Recurrence, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.Contains(strings.ToUpper(expr), "FREQ=") {
+		return ParseCron(expr, loc)
+	}
+
+	start := time.Date(defaultStart.Year(), defaultStart.Month(), defaultStart.Day(), 0, 0, 0, 0, loc)
+	var rule string
+	for _, line := range strings.Fields(expr) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			rule = line
+			continue
+		}
+
+		switch params, _, _ := strings.Cut(strings.ToUpper(name), ";"); params {
+		case "RRULE":
+			rule = value
+		case "DTSTART":
+			var err error
+			start, _, err = ParseTime(name, value, loc)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("Invalid recurrence %q", expr)
+		}
+	}
+
+	return ParseRRule(rule, start)
+}
+
+// ParseTime parses an iCalendar date or date-time value of the property
+// with the given name and parameters (e.g. "DTSTART;TZID=Europe/Berlin").
+// Floating times are interpreted in loc. Dates are reported as such.
+func ParseTime(property, value string, loc *time.Location) (t time.Time, isDate bool, err error) {
+	for _, param := range strings.Split(property, ";")[1:] {
+		name, paramValue, _ := strings.Cut(param, "=")
+		switch strings.ToUpper(name) {
+		case "TZID":
+			tzLoc, err := time.LoadLocation(strings.Trim(paramValue, `"`))
+			if err == nil {
+				loc = tzLoc
+			}
+		case "VALUE":
+			isDate = strings.EqualFold(paramValue, "DATE")
+		}
+	}
+
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t, true, nil
+	}
+
+	return time.Time{}, isDate, fmt.Errorf("Invalid time %q", value)
+}
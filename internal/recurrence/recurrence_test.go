@@ -0,0 +1,244 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextN(r Recurrence, after time.Time, n int) []time.Time {
+	var occurrences []time.Time
+	for i := 0; i < n; i++ {
+		after = r.Next(after)
+		if after.IsZero() {
+			break
+		}
+		occurrences = append(occurrences, after)
+	}
+	return occurrences
+}
+
+func TestCron(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	// Friday
+	now := time.Date(2026, 10, 16, 12, 30, 0, 0, loc)
+
+	tests := []struct {
+		expr     string
+		expected []time.Time
+	}{
+		{"0 12 * * mon-fri", []time.Time{
+			time.Date(2026, 10, 19, 12, 0, 0, 0, loc),
+			time.Date(2026, 10, 20, 12, 0, 0, 0, loc),
+		}},
+		{"*/15 12 * * *", []time.Time{
+			time.Date(2026, 10, 16, 12, 45, 0, 0, loc),
+			time.Date(2026, 10, 17, 12, 0, 0, 0, loc),
+		}},
+		{"0 16 * * 5", []time.Time{
+			time.Date(2026, 10, 16, 16, 0, 0, 0, loc),
+			time.Date(2026, 10, 23, 16, 0, 0, 0, loc),
+		}},
+		{"30 9 1,15 * sun", []time.Time{
+			time.Date(2026, 10, 18, 9, 30, 0, 0, loc),
+			time.Date(2026, 10, 25, 9, 30, 0, 0, loc),
+			time.Date(2026, 11, 1, 9, 30, 0, 0, loc),
+			time.Date(2026, 11, 8, 9, 30, 0, 0, loc),
+			time.Date(2026, 11, 15, 9, 30, 0, 0, loc),
+		}},
+		{"0 0 29 feb *", []time.Time{
+			time.Date(2028, 2, 29, 0, 0, 0, 0, loc),
+		}},
+		{"@daily", []time.Time{
+			time.Date(2026, 10, 17, 0, 0, 0, 0, loc),
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			r, err := Parse(test.expr, loc)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, nextN(r, now, len(test.expected)))
+		})
+	}
+}
+
+func TestCronDaylightSavingTime(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	r, err := ParseCron("30 2 * * *", loc)
+	require.NoError(t, err)
+
+	// 02:30 does not exist on 2026-03-29.
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 3, 28, 2, 30, 0, 0, loc),
+		time.Date(2026, 3, 30, 2, 30, 0, 0, loc),
+	}, nextN(r, time.Date(2026, 3, 28, 0, 0, 0, 0, loc), 2))
+
+	r, err = ParseCron("0 12 * * *", loc)
+	require.NoError(t, err)
+	next := r.Next(time.Date(2026, 3, 28, 12, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2026, 3, 29, 12, 0, 0, 0, loc), next)
+	assert.Equal(t, 23*time.Hour, next.Sub(time.Date(2026, 3, 28, 12, 0, 0, 0, loc)))
+}
+
+func TestCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * fri-mon",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr, time.UTC)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRRule(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, loc)
+	after := time.Date(2026, 10, 16, 12, 30, 0, 0, loc)
+
+	tests := []struct {
+		rule     string
+		after    time.Time
+		expected []time.Time
+	}{
+		{"FREQ=DAILY", after, []time.Time{
+			time.Date(2026, 10, 17, 10, 0, 0, 0, loc),
+			time.Date(2026, 10, 18, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=DAILY;INTERVAL=3;COUNT=3", start.Add(-time.Hour), []time.Time{
+			time.Date(2026, 1, 5, 10, 0, 0, 0, loc),
+			time.Date(2026, 1, 8, 10, 0, 0, 0, loc),
+			time.Date(2026, 1, 11, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR;BYHOUR=12;BYMINUTE=0", after, []time.Time{
+			time.Date(2026, 10, 19, 12, 0, 0, 0, loc),
+			time.Date(2026, 10, 21, 12, 0, 0, 0, loc),
+			time.Date(2026, 10, 23, 12, 0, 0, 0, loc),
+		}},
+		{"FREQ=WEEKLY;INTERVAL=2", after, []time.Time{
+			time.Date(2026, 10, 26, 10, 0, 0, 0, loc),
+			time.Date(2026, 11, 9, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=WEEKLY;UNTIL=20260120T000000Z", start, []time.Time{
+			time.Date(2026, 1, 12, 10, 0, 0, 0, loc),
+			time.Date(2026, 1, 19, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=MONTHLY;BYDAY=-1FR", after, []time.Time{
+			time.Date(2026, 10, 30, 10, 0, 0, 0, loc),
+			time.Date(2026, 11, 27, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=MONTHLY;BYDAY=1MO,3MO", after, []time.Time{
+			time.Date(2026, 10, 19, 10, 0, 0, 0, loc),
+			time.Date(2026, 11, 2, 10, 0, 0, 0, loc),
+			time.Date(2026, 11, 16, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", after, []time.Time{
+			time.Date(2026, 10, 31, 10, 0, 0, 0, loc),
+			time.Date(2026, 11, 30, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", after, []time.Time{
+			time.Date(2026, 11, 13, 10, 0, 0, 0, loc),
+			time.Date(2027, 8, 13, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=24,31", after, []time.Time{
+			time.Date(2026, 12, 24, 10, 0, 0, 0, loc),
+			time.Date(2026, 12, 31, 10, 0, 0, 0, loc),
+			time.Date(2027, 12, 24, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=YEARLY", after, []time.Time{
+			time.Date(2027, 1, 5, 10, 0, 0, 0, loc),
+		}},
+		{"FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU", after, []time.Time{
+			time.Date(2027, 3, 28, 10, 0, 0, 0, loc),
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rule, func(t *testing.T) {
+			r, err := ParseRRule(test.rule, start)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, nextN(r, test.after, len(test.expected)))
+		})
+	}
+}
+
+func TestRRuleEnds(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+
+	r, err := ParseRRule("FREQ=DAILY;COUNT=2", start)
+	require.NoError(t, err)
+	assert.Len(t, nextN(r, start.Add(-time.Hour), 5), 2)
+
+	r, err = ParseRRule("FREQ=MONTHLY;BYMONTHDAY=30;BYMONTH=2", start)
+	require.NoError(t, err)
+	assert.True(t, r.Next(start).IsZero())
+}
+
+func TestRRuleBetween(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		panic(err)
+	}
+
+	// The occurrences keep their local time across daylight saving time.
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, loc)
+	r, err := ParseRRule("FREQ=WEEKLY;BYDAY=MO", start)
+	require.NoError(t, err)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 3, 2, 9, 0, 0, 0, loc),
+		time.Date(2026, 3, 9, 9, 0, 0, 0, loc),
+		time.Date(2026, 3, 16, 9, 0, 0, 0, loc),
+	}, r.Between(start, time.Date(2026, 3, 17, 0, 0, 0, 0, loc)))
+}
+
+func TestParse(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		panic(err)
+	}
+
+	r, err := Parse("DTSTART;TZID=Europe/Berlin:20261016T160000\nRRULE:FREQ=WEEKLY;INTERVAL=2", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 30, 16, 0, 0, 0, loc), r.Next(time.Date(2026, 10, 16, 16, 0, 0, 0, loc)))
+
+	r, err = Parse("FREQ=WEEKLY;BYDAY=FR;BYHOUR=16", loc)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 23, 16, 0, 0, 0, loc), r.Next(time.Date(2026, 10, 16, 16, 0, 0, 0, loc)))
+
+	for _, expr := range []string{
+		"FREQ=HOURLY",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"BYDAY=MO;FREQ",
+		"DTSTART:yesterday\nRRULE:FREQ=DAILY",
+	} {
+		_, err := Parse(expr, loc)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,428 @@
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency int
+
+const (
+	Daily Frequency = iota
+	Weekly
+	Monthly
+	Yearly
+)
+
+var (
+	frequencies = map[string]Frequency{
+		"DAILY":   Daily,
+		"WEEKLY":  Weekly,
+		"MONTHLY": Monthly,
+		"YEARLY":  Yearly,
+	}
+
+	icalWeekdays = map[string]time.Weekday{
+		"SU": time.Sunday,
+		"MO": time.Monday,
+		"TU": time.Tuesday,
+		"WE": time.Wednesday,
+		"TH": time.Thursday,
+		"FR": time.Friday,
+		"SA": time.Saturday,
+	}
+)
+
+// maxEmptyPeriods bounds the search for the next occurrence of rules that
+// rarely or never match.
+const maxEmptyPeriods = 1000
+
+// WeekdayNum is a BYDAY value like "FR", "1MO" (first Monday) or "-1FR" (last
+// Friday).
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// RRule is a recurrence rule as used in iCalendar. The frequencies DAILY,
+// WEEKLY, MONTHLY and YEARLY are supported with the INTERVAL, COUNT, UNTIL,
+// BYDAY, BYMONTHDAY, BYMONTH, BYHOUR, BYMINUTE and WKST parts.
+type RRule struct {
+	// Start is the first occurrence (DTSTART). It determines the location
+	// and the parts of the occurrences that are not given by the rule.
+	Start time.Time
+
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	ByHour     []int
+	ByMinute   []int
+	WeekStart  time.Weekday
+}
+
+// ParseRRule parses the value of an RRULE property like
+// "FREQ=WEEKLY;BYDAY=MO,WE".
+func ParseRRule(value string, start time.Time) (RRule, error) {
+	invalid := func(part string) error {
+		return fmt.Errorf("Invalid recurrence rule %q: unsupported %s", value, part)
+	}
+
+	r := RRule{
+		Start:     start,
+		Freq:      -1,
+		Interval:  1,
+		WeekStart: time.Monday,
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(value), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+
+		name, partValue, _ := strings.Cut(part, "=")
+		var err error
+		switch name {
+		case "FREQ":
+			freq, ok := frequencies[partValue]
+			if !ok {
+				return RRule{}, invalid(part)
+			}
+			r.Freq = freq
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(partValue)
+			if r.Interval < 1 {
+				err = invalid(part)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(partValue)
+		case "UNTIL":
+			r.Until, _, err = ParseTime(name, partValue, start.Location())
+		case "BYDAY":
+			for _, day := range strings.Split(partValue, ",") {
+				weekday, ok := icalWeekdays[day[max(0, len(day)-2):]]
+				n := 0
+				if ok && len(day) > 2 {
+					n, err = strconv.Atoi(day[:len(day)-2])
+				}
+				if !ok || err != nil {
+					return RRule{}, invalid(part)
+				}
+				r.ByDay = append(r.ByDay, WeekdayNum{Weekday: weekday, N: n})
+			}
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(partValue, -31, 31)
+		case "BYMONTH":
+			r.ByMonth, err = parseInts(partValue, 1, 12)
+		case "BYHOUR":
+			r.ByHour, err = parseInts(partValue, 0, 23)
+		case "BYMINUTE":
+			r.ByMinute, err = parseInts(partValue, 0, 59)
+		case "WKST":
+			weekday, ok := icalWeekdays[partValue]
+			if !ok {
+				return RRule{}, invalid(part)
+			}
+			r.WeekStart = weekday
+		default:
+			return RRule{}, invalid(part)
+		}
+
+		if err != nil {
+			return RRule{}, invalid(part)
+		}
+	}
+
+	if r.Freq < 0 {
+		return RRule{}, fmt.Errorf("Invalid recurrence rule %q: missing FREQ", value)
+	}
+
+	slices.Sort(r.ByHour)
+	slices.Sort(r.ByMinute)
+	return r, nil
+}
+
+func parseInts(value string, min, max int) ([]int, error) {
+	var values []int
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < min || n > max || n == 0 && min < 0 {
+			return nil, fmt.Errorf("Invalid value %q", part)
+		}
+		values = append(values, n)
+	}
+
+	return values, nil
+}
+
+func (r RRule) Next(after time.Time) time.Time {
+	var next time.Time
+	r.each(after, func(t time.Time) bool {
+		if t.After(after) {
+			next = t
+			return false
+		}
+		return true
+	})
+
+	return next
+}
+
+// Between returns the occurrences in [from, to).
+func (r RRule) Between(from, to time.Time) []time.Time {
+	var occurrences []time.Time
+	r.each(from, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return true
+	})
+
+	return occurrences
+}
+
+// each calls fn for the occurrences in order until it returns false. The
+// occurrences before from may be skipped.
+func (r RRule) each(from time.Time, fn func(time.Time) bool) {
+	period := 0
+	if r.Count == 0 && from.After(r.Start) {
+		// Skip the periods before from unless occurrences must be counted.
+		period = max(0, r.periodsUntil(from)/r.Interval-1)
+	}
+
+	count := 0
+	for empty := 0; empty < maxEmptyPeriods; period++ {
+		occurrences := r.occurrences(period)
+		if len(occurrences) == 0 {
+			empty++
+			continue
+		}
+
+		empty = 0
+		for _, t := range occurrences {
+			if t.Before(r.Start) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return
+			}
+
+			count++
+			if r.Count > 0 && count > r.Count {
+				return
+			}
+
+			if !fn(t) {
+				return
+			}
+		}
+	}
+}
+
+// periodStart returns the first day of the nth period.
+func (r RRule) periodStart(n int) time.Time {
+	start := r.Start
+	n *= r.Interval
+	switch r.Freq {
+	case Daily:
+		return date(start.Year(), start.Month(), start.Day()+n, start.Location())
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		return date(start.Year(), start.Month(), start.Day()-offset+7*n, start.Location())
+	case Monthly:
+		return date(start.Year(), start.Month()+time.Month(n), 1, start.Location())
+	default:
+		return date(start.Year()+n, 1, 1, start.Location())
+	}
+}
+
+// periodsUntil returns the number of whole frequency units between the start
+// and t.
+func (r RRule) periodsUntil(t time.Time) int {
+	t = t.In(r.Start.Location())
+	first := r.periodStart(0)
+	switch r.Freq {
+	case Daily, Weekly:
+		days := int(date(t.Year(), t.Month(), t.Day(), time.UTC).Sub(date(first.Year(), first.Month(), first.Day(), time.UTC)).Hours() / 24)
+		if r.Freq == Weekly {
+			return days / 7
+		}
+		return days
+	case Monthly:
+		return (t.Year()-first.Year())*12 + int(t.Month()-first.Month())
+	default:
+		return t.Year() - first.Year()
+	}
+}
+
+// occurrences returns the occurrences of the nth period in order.
+func (r RRule) occurrences(n int) []time.Time {
+	first := r.periodStart(n)
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		if r.matchesMonth(first) && r.matchesMonthDay(first) && r.matchesWeekday(first) {
+			days = []time.Time{first}
+		}
+	case Weekly:
+		for i := 0; i < 7; i++ {
+			day := first.AddDate(0, 0, i)
+			if r.matchesMonth(day) && r.matchesWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		if r.matchesMonth(first) {
+			days = r.monthDays(first)
+		}
+	case Yearly:
+		days = r.yearDays(first)
+	}
+
+	hours := r.ByHour
+	if len(hours) == 0 {
+		hours = []int{r.Start.Hour()}
+	}
+	minutes := r.ByMinute
+	if len(minutes) == 0 {
+		minutes = []int{r.Start.Minute()}
+	}
+
+	var occurrences []time.Time
+	for _, day := range days {
+		for _, hour := range hours {
+			for _, minute := range minutes {
+				occurrences = append(occurrences, time.Date(day.Year(), day.Month(), day.Day(), hour, minute, r.Start.Second(), 0, day.Location()))
+			}
+		}
+	}
+
+	return occurrences
+}
+
+func (r RRule) matchesMonth(day time.Time) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, int(day.Month()))
+}
+
+func (r RRule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	daysInMonth := date(day.Year(), day.Month()+1, 0, time.UTC).Day()
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || monthDay < 0 && daysInMonth+monthDay+1 == day.Day() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesWeekday ignores the ordinals of BYDAY.
+func (r RRule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return r.Freq != Weekly || day.Weekday() == r.Start.Weekday()
+	}
+
+	return slices.ContainsFunc(r.ByDay, func(weekday WeekdayNum) bool {
+		return weekday.Weekday == day.Weekday()
+	})
+}
+
+// monthDays expands the days of the month starting at first.
+func (r RRule) monthDays(first time.Time) []time.Time {
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		return r.monthDaysOf(first, r.Start.Day())
+	}
+
+	return r.filterDays(daysUntil(first, first.AddDate(0, 1, 0)))
+}
+
+// yearDays expands the days of the year starting at first.
+func (r RRule) yearDays(first time.Time) []time.Time {
+	filtered := len(r.ByDay) > 0 || len(r.ByMonthDay) > 0
+	switch {
+	case len(r.ByMonth) > 0:
+		var days []time.Time
+		for _, month := range r.ByMonth {
+			month := date(first.Year(), time.Month(month), 1, first.Location())
+			if filtered {
+				days = append(days, r.filterDays(daysUntil(month, month.AddDate(0, 1, 0)))...)
+			} else {
+				days = append(days, r.monthDaysOf(month, r.Start.Day())...)
+			}
+		}
+		slices.SortFunc(days, func(a, b time.Time) int {
+			return a.Compare(b)
+		})
+		return days
+	case len(r.ByDay) > 0:
+		// The ordinals count within the whole year.
+		return r.filterDays(daysUntil(first, first.AddDate(1, 0, 0)))
+	case len(r.ByMonthDay) > 0:
+		var days []time.Time
+		for month := first; month.Year() == first.Year(); month = month.AddDate(0, 1, 0) {
+			days = append(days, r.filterDays(daysUntil(month, month.AddDate(0, 1, 0)))...)
+		}
+		return days
+	default:
+		return r.monthDaysOf(date(first.Year(), r.Start.Month(), 1, first.Location()), r.Start.Day())
+	}
+}
+
+// monthDaysOf returns the given day of the month if it exists.
+func (r RRule) monthDaysOf(month time.Time, day int) []time.Time {
+	t := date(month.Year(), month.Month(), day, month.Location())
+	if t.Month() != month.Month() {
+		return nil
+	}
+	return []time.Time{t}
+}
+
+// filterDays keeps the days matching BYMONTHDAY and BYDAY. The ordinals of
+// BYDAY count within the given days.
+func (r RRule) filterDays(days []time.Time) []time.Time {
+	var filtered []time.Time
+	for i, day := range days {
+		if !r.matchesMonthDay(day) {
+			continue
+		}
+
+		if len(r.ByDay) == 0 {
+			filtered = append(filtered, day)
+			continue
+		}
+
+		nth := i/7 + 1
+		nthLast := -((len(days)-1-i)/7 + 1)
+		if slices.ContainsFunc(r.ByDay, func(weekday WeekdayNum) bool {
+			return weekday.Weekday == day.Weekday() && (weekday.N == 0 || weekday.N == nth || weekday.N == nthLast)
+		}) {
+			filtered = append(filtered, day)
+		}
+	}
+
+	return filtered
+}
+
+func daysUntil(first, end time.Time) []time.Time {
+	var days []time.Time
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+func date(year int, month time.Month, day int, loc *time.Location) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
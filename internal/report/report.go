@@ -0,0 +1,151 @@
+// Package report sums up the time spent in each status from the history of
+// status changes.
+package report
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+)
+
+// StatusUnknown is reported for the time after a message was cleared while
+// the status was unknown.
+const StatusUnknown = "unknown"
+
+// Span is a period during which a status was in effect.
+type Span struct {
+	Start time.Time
+	End   time.Time
+
+	Status string
+	// Message is the emoji and message, empty if there was none.
+	Message string
+}
+
+// Spans returns the periods between from and to during which the statuses of
+// the history entries were in effect. Every status lasts until the next entry,
+// its message only until it is cleared. Nothing is known before the first
+// entry.
+func Spans(entries []history.Entry, from, to time.Time) []Span {
+	var spans []Span
+	add := func(span Span) {
+		if span.Start.Before(from) {
+			span.Start = from
+		}
+		if span.End.After(to) {
+			span.End = to
+		}
+		if span.Start.Before(span.End) {
+			spans = append(spans, span)
+		}
+	}
+
+	status := StatusUnknown
+	for i, entry := range entries {
+		end := to
+		if i+1 < len(entries) {
+			end = entries[i+1].At
+		}
+
+		// Clearing the message keeps the status.
+		if entry.Status.Status != "" {
+			status = entry.Status.Status
+		}
+		message := strings.TrimSpace(entry.Status.Icon + " " + entry.Status.Message)
+
+		clearAt := time.Unix(entry.Status.ClearAt, 0).In(entry.At.Location())
+		if message != "" && entry.Status.ClearAt > 0 && clearAt.Before(end) {
+			add(Span{Start: entry.At, End: clearAt, Status: status, Message: message})
+			add(Span{Start: clearAt, End: end, Status: status})
+		} else {
+			add(Span{Start: entry.At, End: end, Status: status, Message: message})
+		}
+	}
+
+	return spans
+}
+
+type Report struct {
+	From time.Time
+	To   time.Time
+	Days []Day
+}
+
+type Day struct {
+	Date     time.Time
+	Statuses map[string]time.Duration
+	Messages map[string]time.Duration
+}
+
+// New sums up the time in each status and with each message between from and
+// to for every day of the calendar.
+func New(cal calendar.Calendar, entries []history.Entry, from, to time.Time) Report {
+	report := Report{From: from, To: to}
+	for date := cal.Day(from); cal.StartOfDay(date).Before(to); date = date.AddDate(0, 0, 1) {
+		start := maxTime(from, cal.StartOfDay(date))
+		end := minTime(to, cal.EndOfDay(date))
+
+		day := Day{Date: date, Statuses: map[string]time.Duration{}, Messages: map[string]time.Duration{}}
+		for _, span := range Spans(entries, start, end) {
+			duration := span.End.Sub(span.Start)
+			day.Statuses[span.Status] += duration
+			if span.Message != "" {
+				day.Messages[span.Message] += duration
+			}
+		}
+		report.Days = append(report.Days, day)
+	}
+
+	return report
+}
+
+// Statuses returns the total time in each status.
+func (r Report) Statuses() map[string]time.Duration {
+	totals := map[string]time.Duration{}
+	for _, day := range r.Days {
+		for status, duration := range day.Statuses {
+			totals[status] += duration
+		}
+	}
+	return totals
+}
+
+// Messages returns the total time with each message.
+func (r Report) Messages() map[string]time.Duration {
+	totals := map[string]time.Duration{}
+	for _, day := range r.Days {
+		for message, duration := range day.Messages {
+			totals[message] += duration
+		}
+	}
+	return totals
+}
+
+// ByDuration returns the keys of the totals from the longest to the shortest
+// duration.
+func ByDuration(totals map[string]time.Duration) []string {
+	keys := slices.Sorted(maps.Keys(totals))
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return cmp.Compare(totals[b], totals[a])
+	})
+	return keys
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/history"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+)
+
+var monday = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+func at(day, hour, minute int) time.Time {
+	return monday.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+func entry(t time.Time, status ocs.UserStatus) history.Entry {
+	return history.Entry{At: t, Source: history.SourceNsc, Status: status}
+}
+
+func TestSpans(t *testing.T) {
+	entries := []history.Entry{
+		entry(at(0, 9, 0), ocs.UserStatus{Status: "online"}),
+		entry(at(0, 10, 0), ocs.UserStatus{Status: "dnd", Icon: "🎧", Message: "Focus", ClearAt: at(0, 11, 0).Unix()}),
+		entry(at(0, 12, 0), ocs.UserStatus{Status: "away", Message: "Lunch"}),
+		entry(at(0, 12, 30), ocs.UserStatus{}),
+	}
+
+	assert.Equal(t, []Span{
+		{Start: at(0, 9, 30), End: at(0, 10, 0), Status: "online"},
+		{Start: at(0, 10, 0), End: at(0, 11, 0), Status: "dnd", Message: "🎧 Focus"},
+		{Start: at(0, 11, 0), End: at(0, 12, 0), Status: "dnd"},
+		{Start: at(0, 12, 0), End: at(0, 12, 30), Status: "away", Message: "Lunch"},
+		{Start: at(0, 12, 30), End: at(0, 13, 0), Status: "away"},
+	}, Spans(entries, at(0, 9, 30), at(0, 13, 0)))
+
+	// Nothing is known before the first entry.
+	assert.Empty(t, Spans(entries, at(0, 8, 0), at(0, 9, 0)))
+
+	// The status of a message cleared while it was unknown is unknown.
+	assert.Equal(t, []Span{{Start: at(0, 12, 30), End: at(0, 13, 0), Status: StatusUnknown}}, Spans(entries[3:], at(0, 12, 0), at(0, 13, 0)))
+}
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+	cal := calendar.Default()
+	cal.Location = time.UTC
+
+	entries := []history.Entry{
+		entry(at(0, 22, 0), ocs.UserStatus{Status: "dnd", Message: "On call"}),
+		entry(at(1, 2, 0), ocs.UserStatus{Status: "online"}),
+		entry(at(1, 12, 0), ocs.UserStatus{Status: "away", Message: "Lunch", ClearAt: at(1, 13, 0).Unix()}),
+	}
+
+	report := New(cal, entries, monday, at(1, 14, 0))
+	assert.Len(report.Days, 2)
+	assert.Equal(monday, report.Days[0].Date)
+	assert.Equal(map[string]time.Duration{"dnd": 2 * time.Hour}, report.Days[0].Statuses)
+	assert.Equal(map[string]time.Duration{"dnd": 2 * time.Hour, "online": 10 * time.Hour, "away": 2 * time.Hour}, report.Days[1].Statuses)
+	assert.Equal(map[string]time.Duration{"On call": 2 * time.Hour, "Lunch": time.Hour}, report.Days[1].Messages)
+
+	statuses := report.Statuses()
+	assert.Equal(map[string]time.Duration{"dnd": 4 * time.Hour, "online": 10 * time.Hour, "away": 2 * time.Hour}, statuses)
+	assert.Equal([]string{"online", "dnd", "away"}, ByDuration(statuses))
+	assert.Equal(map[string]time.Duration{"On call": 4 * time.Hour, "Lunch": time.Hour}, report.Messages())
+}
@@ -0,0 +1,294 @@
+// Package rules applies recurring status changes.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/jsonfile"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/st3iny/nextcloud-status-command/internal/recurrence"
+)
+
+// maxCatchUp limits how far back occurrences are applied after the daemon
+// was not running.
+const maxCatchUp = 24 * time.Hour
+
+type Rule struct {
+	Name       string
+	Recurrence recurrence.Recurrence
+	Status     ocs.UserStatus
+
+	// Timeout is resolved relative to each occurrence.
+	Timeout string
+}
+
+// Load parses the rules of the config.
+func Load(cfg config.Config, cal calendar.Calendar) ([]Rule, error) {
+	var rules []Rule
+	for _, rule := range cfg.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("Rule %q has no name", rule.When)
+		}
+		if slices.ContainsFunc(rules, func(other Rule) bool { return other.Name == rule.Name }) {
+			return nil, fmt.Errorf("Rule %s is defined twice", rule.Name)
+		}
+
+		r, err := recurrence.Parse(rule.When, cal.Location)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid rule %s: %s", rule.Name, err)
+		}
+
+		timeout := rule.Timeout
+		if timeout == "" {
+			timeout = calendar.PresetNever
+		}
+		if _, err := cal.ParseTimeout(timeout, cal.Now()); err != nil {
+			return nil, fmt.Errorf("Invalid rule %s: %s", rule.Name, err)
+		}
+
+		status := rule.Status
+		if status == "" {
+			status = "online"
+		}
+		if !slices.Contains(ocs.Statuses, status) {
+			return nil, fmt.Errorf("Invalid rule %s: unknown status %q, expected one of %s", rule.Name, status, strings.Join(ocs.Statuses, ", "))
+		}
+
+		rules = append(rules, Rule{
+			Name:       rule.Name,
+			Recurrence: r,
+			Status: ocs.UserStatus{
+				Status:  status,
+				Icon:    rule.Icon,
+				Message: rule.Message,
+			},
+			Timeout: timeout,
+		})
+	}
+
+	return rules, nil
+}
+
+type Occurrence struct {
+	At      time.Time
+	Holiday bool
+	Skipped bool
+}
+
+type state struct {
+	// EvaluatedAt is the time up to which occurrences were applied.
+	EvaluatedAt int64              `json:"evaluatedAt"`
+	Skipped     map[string][]int64 `json:"skipped,omitempty"`
+
+	// Retry holds the occurrence of each rule that could not be applied
+	// because the server was unreachable.
+	Retry map[string]int64 `json:"retry,omitempty"`
+}
+
+// Store keeps the state of the rules in a JSON file.
+type Store struct {
+	Path string
+}
+
+// DefaultStore returns the store of the current profile in the XDG state
+// directory.
+func DefaultStore() (Store, error) {
+	name := "nsc/rules.json"
+	if profile := ocs.Profile(); profile != "" {
+		name = filepath.Join("nsc", "profiles", profile, "rules.json")
+	}
+
+	path, err := xdg.StateFile(name)
+	if err != nil {
+		return Store{}, err
+	}
+
+	return Store{Path: path}, nil
+}
+
+func (s Store) load() (state, error) {
+	var st state
+	err := jsonfile.Read(s.Path, &st)
+	return st, err
+}
+
+// update loads the state, modifies it and saves it again while holding a
+// lock, as rules may be skipped while the daemon applies them.
+func (s Store) update(modify func(st *state) error) error {
+	var st state
+	return jsonfile.Update(s.Path, &st, func() error {
+		return modify(&st)
+	})
+}
+
+// Runner applies the occurrences of rules. It is driven by the caller's clock
+// to stay deterministic.
+type Runner struct {
+	Rules    []Rule
+	Calendar calendar.Calendar
+	Store    Store
+
+	// Grace is the delay after which an occurrence without a timeout is no
+	// longer applied.
+	Grace time.Duration
+
+	// Apply sets the status of an occurrence.
+	Apply func(status ocs.UserStatus) error
+}
+
+func (r Runner) rule(name string) (Rule, error) {
+	i := slices.IndexFunc(r.Rules, func(rule Rule) bool {
+		return rule.Name == name
+	})
+	if i < 0 {
+		return Rule{}, fmt.Errorf("No rule named %s", name)
+	}
+
+	return r.Rules[i], nil
+}
+
+// Upcoming returns the next n occurrences of the named rule after the given
+// time including skipped ones.
+func (r Runner) Upcoming(name string, after time.Time, n int) ([]Occurrence, error) {
+	rule, err := r.rule(name)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := r.Store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var occurrences []Occurrence
+	for at := rule.Recurrence.Next(after); !at.IsZero() && len(occurrences) < n; at = rule.Recurrence.Next(at) {
+		occurrences = append(occurrences, Occurrence{
+			At:      at,
+			Holiday: r.Calendar.IsHoliday(at),
+			Skipped: slices.Contains(st.Skipped[name], at.Unix()),
+		})
+	}
+
+	return occurrences, nil
+}
+
+// Skip skips the next occurrence of the named rule after the given time that
+// would be applied and returns it.
+func (r Runner) Skip(name string, after time.Time) (time.Time, error) {
+	occurrences, err := r.Upcoming(name, after, 1000)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	i := slices.IndexFunc(occurrences, func(occurrence Occurrence) bool {
+		return !occurrence.Holiday && !occurrence.Skipped
+	})
+	if i < 0 {
+		return time.Time{}, fmt.Errorf("Rule %s has no upcoming occurrence", name)
+	}
+
+	at := occurrences[i].At
+	return at, r.Store.update(func(st *state) error {
+		if st.Skipped == nil {
+			st.Skipped = map[string][]int64{}
+		}
+		st.Skipped[name] = append(st.Skipped[name], at.Unix())
+		return nil
+	})
+}
+
+type due struct {
+	rule   string
+	at     time.Time
+	status ocs.UserStatus
+}
+
+// Tick applies the latest occurrence of each rule since the previous tick.
+// Occurrences that fall on a holiday or were skipped are ignored. Nothing is
+// applied on the first tick. Occurrences that fail because the server is
+// unreachable are retried on the next tick, others are dropped.
+func (r Runner) Tick(now time.Time) error {
+	st, err := r.Store.load()
+	if err != nil {
+		return err
+	}
+
+	from := time.Unix(st.EvaluatedAt, 0)
+	if st.EvaluatedAt == 0 {
+		from = now
+	} else if from.Before(now.Add(-maxCatchUp)) {
+		from = now.Add(-maxCatchUp)
+	}
+
+	var errs []error
+	var pending []due
+	for _, rule := range r.Rules {
+		var latest time.Time
+		if retry, ok := st.Retry[rule.Name]; ok && !slices.Contains(st.Skipped[rule.Name], retry) {
+			latest = time.Unix(retry, 0)
+		}
+		for at := rule.Recurrence.Next(from); !at.IsZero() && !at.After(now); at = rule.Recurrence.Next(at) {
+			if !r.Calendar.IsHoliday(at) && !slices.Contains(st.Skipped[rule.Name], at.Unix()) {
+				latest = at
+			}
+		}
+		if latest.IsZero() {
+			continue
+		}
+
+		status := rule.Status
+		status.ClearAt, err = r.Calendar.ParseTimeout(rule.Timeout, latest)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid timeout of rule %s: %s", rule.Name, err))
+			continue
+		}
+
+		if status.ClearAt > 0 && status.ClearAt <= now.Unix() || status.ClearAt == 0 && now.Sub(latest) > r.Grace {
+			continue
+		}
+
+		pending = append(pending, due{rule: rule.Name, at: latest, status: status})
+	}
+
+	slices.SortStableFunc(pending, func(a, b due) int {
+		return a.at.Compare(b.at)
+	})
+
+	retry := map[string]int64{}
+	for _, occurrence := range pending {
+		err := r.Apply(occurrence.status)
+		if ocs.IsTransportError(err) {
+			retry[occurrence.rule] = occurrence.at.Unix()
+			errs = append(errs, err)
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("Dropped occurrence of rule %s: %s", occurrence.rule, err))
+		}
+	}
+
+	// The state is loaded again, so occurrences skipped while the statuses
+	// were applied are kept.
+	errs = append(errs, r.Store.update(func(st *state) error {
+		st.EvaluatedAt = now.Unix()
+		st.Retry = retry
+
+		// Forget skipped occurrences that have passed.
+		for name, skipped := range st.Skipped {
+			st.Skipped[name] = slices.DeleteFunc(skipped, func(at int64) bool {
+				return at <= st.EvaluatedAt
+			})
+			if len(st.Skipped[name]) == 0 {
+				delete(st.Skipped, name)
+			}
+		}
+		return nil
+	}))
+	return errors.Join(errs...)
+}
@@ -0,0 +1,215 @@
+package rules
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/calendar"
+	"github.com/st3iny/nextcloud-status-command/internal/config"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	applied []ocs.UserStatus
+	err     error
+}
+
+func (r *recorder) apply(status ocs.UserStatus) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.applied = append(r.applied, status)
+	return nil
+}
+
+func newRunner(t *testing.T, cfg config.Config) (Runner, *recorder) {
+	cal, err := calendar.FromConfig(cfg, "Europe/Berlin")
+	require.NoError(t, err)
+
+	rules, err := Load(cfg, cal)
+	require.NoError(t, err)
+
+	rec := &recorder{}
+	return Runner{
+		Rules:    rules,
+		Calendar: cal,
+		Store:    Store{Path: filepath.Join(t.TempDir(), "rules.json")},
+		Grace:    15 * time.Minute,
+		Apply:    rec.apply,
+	}, rec
+}
+
+var testConfig = config.Config{
+	Rules: []config.Rule{
+		{Name: "lunch", When: "0 12 * * mon-fri", Status: "away", Icon: "🍔", Message: "Lunch", Timeout: "45m"},
+		{Name: "weekend", When: "FREQ=WEEKLY;BYDAY=FR;BYHOUR=16;BYMINUTE=0", Icon: "🍻", Timeout: "today"},
+	},
+	Holidays: []string{"2026-10-23"},
+}
+
+func TestTick(t *testing.T) {
+	assert := assert.New(t)
+	runner, rec := newRunner(t, testConfig)
+	loc := runner.Calendar.Location
+
+	// Nothing is applied on the first tick.
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 11, 0, 0, 0, loc)))
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 11, 59, 0, 0, loc)))
+	assert.Empty(rec.applied)
+
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 12, 0, 0, 0, loc)))
+	assert.Equal([]ocs.UserStatus{{
+		Status:  "away",
+		Icon:    "🍔",
+		Message: "Lunch",
+		ClearAt: time.Date(2026, 10, 19, 12, 45, 0, 0, loc).Unix(),
+	}}, rec.applied)
+
+	// Occurrences are applied once.
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 12, 1, 0, 0, loc)))
+	assert.Len(rec.applied, 1)
+
+	// Missed occurrences are caught up while their status lasts.
+	assert.NoError(runner.Tick(time.Date(2026, 10, 20, 12, 30, 0, 0, loc)))
+	assert.Len(rec.applied, 2)
+	assert.NoError(runner.Tick(time.Date(2026, 10, 21, 13, 0, 0, 0, loc)))
+	assert.Len(rec.applied, 2)
+
+	// Friday is a holiday.
+	assert.NoError(runner.Tick(time.Date(2026, 10, 23, 18, 0, 0, 0, loc)))
+	assert.Len(rec.applied, 2)
+
+	assert.NoError(runner.Tick(time.Date(2026, 10, 30, 16, 5, 0, 0, loc)))
+	assert.Equal(ocs.UserStatus{
+		Status:  "online",
+		Icon:    "🍻",
+		ClearAt: time.Date(2026, 10, 31, 0, 0, 0, 0, loc).Unix(),
+	}, rec.applied[len(rec.applied)-1])
+}
+
+func TestSkip(t *testing.T) {
+	assert := assert.New(t)
+	runner, rec := newRunner(t, testConfig)
+	loc := runner.Calendar.Location
+
+	now := time.Date(2026, 10, 19, 13, 0, 0, 0, loc)
+	assert.NoError(runner.Tick(now))
+
+	at, err := runner.Skip("lunch", now)
+	assert.NoError(err)
+	assert.Equal(time.Date(2026, 10, 20, 12, 0, 0, 0, loc), at)
+
+	// The next skip continues after the skipped occurrence.
+	at, err = runner.Skip("weekend", now)
+	assert.NoError(err)
+	assert.Equal(time.Date(2026, 10, 30, 16, 0, 0, 0, loc), at)
+
+	upcoming, err := runner.Upcoming("weekend", now, 2)
+	assert.NoError(err)
+	assert.Equal([]Occurrence{
+		{At: time.Date(2026, 10, 23, 16, 0, 0, 0, loc), Holiday: true},
+		{At: time.Date(2026, 10, 30, 16, 0, 0, 0, loc), Skipped: true},
+	}, upcoming)
+
+	assert.NoError(runner.Tick(time.Date(2026, 10, 20, 12, 0, 0, 0, loc)))
+	assert.Empty(rec.applied)
+	assert.NoError(runner.Tick(time.Date(2026, 10, 21, 12, 0, 0, 0, loc)))
+	assert.Len(rec.applied, 1)
+
+	_, err = runner.Skip("dinner", now)
+	assert.Error(err)
+}
+
+func TestSkipWhileTicking(t *testing.T) {
+	assert := assert.New(t)
+	runner, _ := newRunner(t, testConfig)
+	loc := runner.Calendar.Location
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 11, 0, 0, 0, loc)))
+
+	// A rule skipped while a status is applied stays skipped.
+	runner.Apply = func(status ocs.UserStatus) error {
+		_, err := runner.Skip("lunch", time.Date(2026, 10, 19, 12, 0, 0, 0, loc))
+		return err
+	}
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 12, 0, 0, 0, loc)))
+
+	upcoming, err := runner.Upcoming("lunch", time.Date(2026, 10, 19, 12, 0, 0, 0, loc), 1)
+	assert.NoError(err)
+	assert.Equal([]Occurrence{{At: time.Date(2026, 10, 20, 12, 0, 0, 0, loc), Skipped: true}}, upcoming)
+}
+
+func TestTickRetriesFailures(t *testing.T) {
+	assert := assert.New(t)
+	runner, rec := newRunner(t, testConfig)
+	loc := runner.Calendar.Location
+
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 11, 0, 0, 0, loc)))
+
+	rec.err = &ocs.TransportError{Err: errors.New("offline")}
+	assert.Error(runner.Tick(time.Date(2026, 10, 19, 12, 0, 0, 0, loc)))
+
+	rec.err = nil
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 12, 1, 0, 0, loc)))
+	assert.Len(rec.applied, 1)
+
+	// Occurrences rejected by the server are not retried.
+	rec.err = errors.New("Invalid status")
+	assert.Error(runner.Tick(time.Date(2026, 10, 20, 12, 0, 0, 0, loc)))
+	rec.err = nil
+	assert.NoError(runner.Tick(time.Date(2026, 10, 20, 12, 1, 0, 0, loc)))
+	assert.Len(rec.applied, 1)
+}
+
+func TestTickRetriesOnlyFailedRules(t *testing.T) {
+	assert := assert.New(t)
+	runner, rec := newRunner(t, config.Config{Rules: []config.Rule{
+		{Name: "lunch", When: "0 12 * * *", Status: "away", Timeout: "1h"},
+		{Name: "focus", When: "0 12 * * *", Status: "dnd", Timeout: "1h"},
+	}})
+	loc := runner.Calendar.Location
+
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 11, 0, 0, 0, loc)))
+
+	offline := true
+	runner.Apply = func(status ocs.UserStatus) error {
+		if status.Status == "dnd" && offline {
+			return &ocs.TransportError{Err: errors.New("offline")}
+		}
+		return rec.apply(status)
+	}
+	assert.Error(runner.Tick(time.Date(2026, 10, 19, 12, 0, 0, 0, loc)))
+	assert.Error(runner.Tick(time.Date(2026, 10, 19, 12, 1, 0, 0, loc)))
+
+	// The status that was applied is not sent again.
+	offline = false
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 12, 2, 0, 0, loc)))
+	assert.NoError(runner.Tick(time.Date(2026, 10, 19, 12, 3, 0, 0, loc)))
+	if assert.Len(rec.applied, 2) {
+		assert.Equal("away", rec.applied[0].Status)
+		assert.Equal("dnd", rec.applied[1].Status)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	cal := calendar.Default()
+	for _, rule := range []config.Rule{
+		{When: "0 12 * * *"},
+		{Name: "lunch", When: "at noon"},
+		{Name: "lunch", When: "0 12 * * *", Timeout: "soon"},
+		{Name: "lunch", When: "0 12 * * *", Status: "busy"},
+	} {
+		_, err := Load(config.Config{Rules: []config.Rule{rule}}, cal)
+		assert.Error(t, err, rule)
+	}
+
+	_, err := Load(config.Config{Rules: []config.Rule{
+		{Name: "lunch", When: "0 12 * * *"},
+		{Name: "lunch", When: "0 13 * * *"},
+	}}, cal)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+// Runner applies due schedules. It is driven by the caller's clock to stay
+// deterministic.
+type Runner struct {
+	Store   Store
+	CatchUp CatchUp
+
+	// Grace is the delay after which a schedule counts as missed and is
+	// handled according to CatchUp.
+	Grace time.Duration
+
+	// Apply sets the scheduled status.
+	Apply func(status ocs.UserStatus) error
+}
+
+// Tick applies the schedules that are due at now. Schedules that fail because
+// the server is not reachable are kept and retried on the next tick, the ones
+// rejected by the server are dropped.
+func (r Runner) Tick(now time.Time) error {
+	due, err := r.Store.take(now)
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	var failed []Entry
+	var errs []error
+	for _, entry := range r.pick(due, now) {
+		err := r.Apply(entry.Status)
+		if ocs.IsTransportError(err) {
+			failed = append(failed, entry)
+			errs = append(errs, err)
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("Dropped schedule %s: %s", entry.Id, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		errs = append(errs, r.Store.putBack(failed))
+	}
+
+	return errors.Join(errs...)
+}
+
+// pick selects the due schedules to apply according to the catch up policy.
+// Schedules whose status would already be cleared again are dropped.
+func (r Runner) pick(due []Entry, now time.Time) []Entry {
+	due = slices.DeleteFunc(due, func(entry Entry) bool {
+		return entry.Status.ClearAt > 0 && entry.Status.ClearAt <= now.Unix()
+	})
+	if len(due) == 0 {
+		return nil
+	}
+
+	missed := func(entry Entry) bool {
+		return now.Sub(time.Unix(entry.At, 0)) > r.Grace
+	}
+
+	switch r.CatchUp {
+	case CatchUpAll:
+		return due
+	case CatchUpSkip:
+		var onTime []Entry
+		for _, entry := range due {
+			if !missed(entry) {
+				onTime = append(onTime, entry)
+			}
+		}
+		return onTime
+	default:
+		// Only the latest schedule matters as it overrides the earlier ones.
+		return due[len(due)-1:]
+	}
+}
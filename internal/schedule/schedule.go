@@ -0,0 +1,173 @@
+// Package schedule persists status changes that are applied at a later time.
+package schedule
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/st3iny/nextcloud-status-command/internal/jsonfile"
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+)
+
+// CatchUp decides what happens to schedules that were missed because neither
+// the daemon nor nsc was running at their time.
+type CatchUp string
+
+const (
+	// CatchUpLatest applies only the latest due schedule.
+	CatchUpLatest CatchUp = "latest"
+	// CatchUpAll applies all due schedules in order.
+	CatchUpAll CatchUp = "all"
+	// CatchUpSkip drops missed schedules.
+	CatchUpSkip CatchUp = "skip"
+)
+
+// DefaultGrace is the delay after which a schedule counts as missed.
+const DefaultGrace = 15 * time.Minute
+
+func ParseCatchUp(value string) (CatchUp, error) {
+	switch catchUp := CatchUp(value); catchUp {
+	case "":
+		return CatchUpLatest, nil
+	case CatchUpLatest, CatchUpAll, CatchUpSkip:
+		return catchUp, nil
+	default:
+		return "", fmt.Errorf("Invalid catch up policy %q", value)
+	}
+}
+
+type Entry struct {
+	Id     string         `json:"id"`
+	At     int64          `json:"at"`
+	Status ocs.UserStatus `json:"status"`
+}
+
+type state struct {
+	NextId  int     `json:"nextId"`
+	Entries []Entry `json:"schedules"`
+}
+
+// Store keeps the schedules in a JSON file.
+type Store struct {
+	Path string
+}
+
+// DefaultStore returns the store of the current profile in the XDG state
+// directory.
+func DefaultStore() (Store, error) {
+	name := "nsc/schedules.json"
+	if profile := ocs.Profile(); profile != "" {
+		name = filepath.Join("nsc", "profiles", profile, "schedules.json")
+	}
+
+	path, err := xdg.StateFile(name)
+	if err != nil {
+		return Store{}, err
+	}
+
+	return Store{Path: path}, nil
+}
+
+func (s Store) load() (state, error) {
+	st := state{NextId: 1}
+	err := jsonfile.Read(s.Path, &st)
+	return st, err
+}
+
+// update loads the state, modifies it and saves it again while holding a
+// lock, as the daemon and nsc may apply schedules at the same time.
+func (s Store) update(modify func(st *state) error) error {
+	st := state{NextId: 1}
+	return jsonfile.Update(s.Path, &st, func() error {
+		return modify(&st)
+	})
+}
+
+// List returns all pending schedules ordered by their time.
+func (s Store) List() ([]Entry, error) {
+	st, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return st.Entries, nil
+}
+
+// Add persists a new schedule and returns it with its id.
+func (s Store) Add(at time.Time, status ocs.UserStatus) (Entry, error) {
+	var entry Entry
+	err := s.update(func(st *state) error {
+		entry = Entry{
+			Id:     strconv.Itoa(st.NextId),
+			At:     at.Unix(),
+			Status: status,
+		}
+		st.NextId++
+		st.Entries = append(st.Entries, entry)
+		sortEntries(st.Entries)
+		return nil
+	})
+
+	return entry, err
+}
+
+func (s Store) Cancel(id string) error {
+	return s.update(func(st *state) error {
+		i := slices.IndexFunc(st.Entries, func(entry Entry) bool {
+			return entry.Id == id
+		})
+		if i < 0 {
+			return fmt.Errorf("No schedule with id %s", id)
+		}
+
+		st.Entries = slices.Delete(st.Entries, i, i+1)
+		return nil
+	})
+}
+
+// take removes all schedules that are due at now.
+func (s Store) take(now time.Time) ([]Entry, error) {
+	isDue := func(entry Entry) bool {
+		return entry.At <= now.Unix()
+	}
+
+	// Most of the time nothing is due, so the lock is only taken if there
+	// is something to take.
+	if st, err := s.load(); err != nil || !slices.ContainsFunc(st.Entries, isDue) {
+		return nil, err
+	}
+
+	var due []Entry
+	err := s.update(func(st *state) error {
+		st.Entries = slices.DeleteFunc(st.Entries, func(entry Entry) bool {
+			if !isDue(entry) {
+				return false
+			}
+
+			due = append(due, entry)
+			return true
+		})
+		return nil
+	})
+
+	return due, err
+}
+
+// putBack restores schedules that failed to apply.
+func (s Store) putBack(entries []Entry) error {
+	return s.update(func(st *state) error {
+		st.Entries = append(st.Entries, entries...)
+		sortEntries(st.Entries)
+		return nil
+	})
+}
+
+func sortEntries(entries []Entry) {
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return int(a.At - b.At)
+	})
+}
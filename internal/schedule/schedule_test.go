@@ -0,0 +1,166 @@
+package schedule
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/st3iny/nextcloud-status-command/internal/ocs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+
+func newStore(t *testing.T) Store {
+	return Store{Path: filepath.Join(t.TempDir(), "schedules.json")}
+}
+
+func TestAddListCancel(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+
+	entries, err := store.List()
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	lunch, err := store.Add(start, ocs.UserStatus{Status: "away", Icon: "🍔", Message: "Lunch"})
+	require.NoError(t, err)
+	meeting, err := store.Add(start.Add(-time.Hour), ocs.UserStatus{Status: "dnd", Message: "Meeting"})
+	require.NoError(t, err)
+	assert.Equal("1", lunch.Id)
+	assert.Equal("2", meeting.Id)
+
+	entries, err = store.List()
+	assert.NoError(err)
+	assert.Equal([]Entry{meeting, lunch}, entries)
+
+	assert.NoError(store.Cancel("2"))
+	assert.Error(store.Cancel("2"))
+
+	entries, err = store.List()
+	assert.NoError(err)
+	assert.Equal([]Entry{lunch}, entries)
+
+	// Ids are not reused.
+	next, err := store.Add(start, ocs.UserStatus{Status: "online"})
+	assert.NoError(err)
+	assert.Equal("3", next.Id)
+}
+
+type recorder struct {
+	applied []string
+	err     error
+}
+
+func (r *recorder) apply(status ocs.UserStatus) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.applied = append(r.applied, status.Message)
+	return nil
+}
+
+func TestRunnerAppliesDueSchedules(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+	rec := &recorder{}
+	runner := Runner{Store: store, CatchUp: CatchUpLatest, Grace: DefaultGrace, Apply: rec.apply}
+
+	store.Add(start, ocs.UserStatus{Status: "away", Message: "Lunch", ClearAt: start.Add(time.Hour).Unix()})
+	store.Add(start.Add(2*time.Hour), ocs.UserStatus{Status: "dnd", Message: "Meeting"})
+
+	assert.NoError(runner.Tick(start.Add(-time.Second)))
+	assert.Empty(rec.applied)
+
+	assert.NoError(runner.Tick(start))
+	assert.Equal([]string{"Lunch"}, rec.applied)
+
+	// Applied schedules are removed.
+	assert.NoError(runner.Tick(start.Add(time.Minute)))
+	assert.Equal([]string{"Lunch"}, rec.applied)
+
+	entries, err := store.List()
+	assert.NoError(err)
+	assert.Len(entries, 1)
+}
+
+func TestRunnerCatchUp(t *testing.T) {
+	tests := []struct {
+		catchUp  CatchUp
+		expected []string
+	}{
+		{CatchUpLatest, []string{"late"}},
+		{CatchUpAll, []string{"missed", "late"}},
+		{CatchUpSkip, []string{"late"}},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.catchUp), func(t *testing.T) {
+			store := newStore(t)
+			rec := &recorder{}
+			runner := Runner{Store: store, CatchUp: test.catchUp, Grace: DefaultGrace, Apply: rec.apply}
+
+			store.Add(start.Add(-3*time.Hour), ocs.UserStatus{Status: "away", Message: "missed"})
+			store.Add(start.Add(-2*time.Hour), ocs.UserStatus{Status: "away", Message: "expired", ClearAt: start.Add(-time.Hour).Unix()})
+			store.Add(start.Add(-time.Minute), ocs.UserStatus{Status: "dnd", Message: "late"})
+			store.Add(start.Add(time.Hour), ocs.UserStatus{Status: "dnd", Message: "future"})
+
+			assert.NoError(t, runner.Tick(start))
+			assert.Equal(t, test.expected, rec.applied)
+
+			entries, err := store.List()
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestRunnerRetriesFailedSchedules(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+	rec := &recorder{err: &ocs.TransportError{Err: errors.New("offline")}}
+	runner := Runner{Store: store, CatchUp: CatchUpSkip, Grace: DefaultGrace, Apply: rec.apply}
+
+	store.Add(start, ocs.UserStatus{Status: "away", Message: "Lunch"})
+
+	assert.Error(runner.Tick(start))
+	entries, err := store.List()
+	assert.NoError(err)
+	assert.Len(entries, 1)
+
+	rec.err = nil
+	assert.NoError(runner.Tick(start.Add(time.Minute)))
+	assert.Equal([]string{"Lunch"}, rec.applied)
+
+	// Missed schedules are dropped.
+	store.Add(start, ocs.UserStatus{Status: "away", Message: "Coffee"})
+	assert.NoError(runner.Tick(start.Add(DefaultGrace + time.Minute)))
+	assert.Equal([]string{"Lunch"}, rec.applied)
+	entries, err = store.List()
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	// Schedules rejected by the server are dropped.
+	rec.err = errors.New("invalid status")
+	store.Add(start, ocs.UserStatus{Status: "busy"})
+	assert.Error(runner.Tick(start.Add(time.Minute)))
+	entries, err = store.List()
+	assert.NoError(err)
+	assert.Empty(entries)
+}
+
+func TestParseCatchUp(t *testing.T) {
+	catchUp, err := ParseCatchUp("")
+	assert.NoError(t, err)
+	assert.Equal(t, CatchUpLatest, catchUp)
+
+	catchUp, err = ParseCatchUp("all")
+	assert.NoError(t, err)
+	assert.Equal(t, CatchUpAll, catchUp)
+
+	_, err = ParseCatchUp("sometimes")
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+// Package sdnotify implements the client side of the systemd notification
+// protocol. All functions are no-ops if the process was not started by
+// systemd with a notification socket.
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+func Notify(state string) error {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return nil
+	}
+
+	// A leading @ denotes a socket in the abstract namespace.
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+func Ready() error {
+	return Notify("READY=1")
+}
+
+func Stopping() error {
+	return Notify("STOPPING=1")
+}
+
+func Status(status string) error {
+	return Notify("STATUS=" + status)
+}
+
+func Watchdog() error {
+	return Notify("WATCHDOG=1")
+}
+
+// WatchdogInterval returns the interval in which systemd expects watchdog
+// pings or 0 if the watchdog is disabled for this process.
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,68 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listen(t *testing.T) *net.UnixConn {
+	socketPath := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+	return conn
+}
+
+func receive(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	conn := listen(t)
+
+	assert.NoError(t, Ready())
+	assert.Equal(t, "READY=1", receive(t, conn))
+
+	assert.NoError(t, Status("online 🍔 Lunch"))
+	assert.Equal(t, "STATUS=online 🍔 Lunch", receive(t, conn))
+
+	assert.NoError(t, Watchdog())
+	assert.Equal(t, "WATCHDOG=1", receive(t, conn))
+
+	assert.NoError(t, Stopping())
+	assert.Equal(t, "STOPPING=1", receive(t, conn))
+}
+
+func TestNotifyWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	assert.NoError(t, Ready())
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("WATCHDOG_USEC", "")
+	t.Setenv("WATCHDOG_PID", "")
+	assert.Equal(time.Duration(0), WatchdogInterval())
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	assert.Equal(30*time.Second, WatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	assert.Equal(30*time.Second, WatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", "1")
+	assert.Equal(time.Duration(0), WatchdogInterval())
+}